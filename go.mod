@@ -3,9 +3,10 @@ module github.com/allanore/aztest
 go 1.13
 
 require (
-	github.com/Azure/azure-sdk-for-go v40.4.0+incompatible
-	github.com/Azure/go-autorest/autorest v0.10.0
-	github.com/Azure/go-autorest/autorest/azure/auth v0.4.2
+	github.com/Azure/azure-sdk-for-go v68.0.0+incompatible
+	github.com/Azure/go-autorest/autorest v0.11.29
+	github.com/Azure/go-autorest/autorest/azure/auth v0.5.12
+	github.com/gofrs/uuid v4.4.0+incompatible // indirect
 	github.com/gruntwork-io/terratest v0.26.0
 	github.com/stretchr/testify v1.5.1
 )
@@ -0,0 +1,702 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ApplicationGatewayBackendHealthy is the health reported by the backend health API for a server passing its probe.
+// The Azure Portal shows it as Healthy.
+const ApplicationGatewayBackendHealthy = "Up"
+
+// ApplicationGatewayBackendHealthTimeout is how long to wait for the backend health of an Application Gateway, which
+// probes every backend server before it returns
+const ApplicationGatewayBackendHealthTimeout = 10 * time.Minute
+
+// ApplicationGateway describes an Azure Application Gateway and its listeners, routing rules and backends
+type ApplicationGateway struct {
+	ID               string
+	Name             string
+	Sku              string
+	Tier             string
+	OperationalState string
+	EnableHTTP2      bool
+	Zones            []string
+
+	// Capacity is the fixed instance count. It is zero for a v2 gateway using autoscaling, in which case MinCapacity
+	// and MaxCapacity are set instead.
+	Capacity    int32
+	MinCapacity int32
+	MaxCapacity int32
+
+	// FirewallPolicyID is the WAF policy associated with the gateway, while WebApplicationFirewall holds the legacy
+	// WAF configuration set directly on the gateway. Either may be empty.
+	FirewallPolicyID       string
+	WebApplicationFirewall ApplicationGatewayWAFConfiguration
+
+	Listeners           []ApplicationGatewayListener
+	RoutingRules        []ApplicationGatewayRoutingRule
+	BackendHTTPSettings []ApplicationGatewayBackendHTTPSettings
+	BackendPools        []ApplicationGatewayBackendPool
+}
+
+// ApplicationGatewayWAFConfiguration describes the WAF configuration set directly on an Application Gateway
+type ApplicationGatewayWAFConfiguration struct {
+	Enabled        bool
+	Mode           string
+	RuleSetType    string
+	RuleSetVersion string
+}
+
+// ApplicationGatewayListener describes an HTTP listener of an Application Gateway. HostNames is empty for a basic
+// listener accepting any host, and the SSL certificate is only set for HTTPS listeners.
+type ApplicationGatewayListener struct {
+	ID                          string
+	Name                        string
+	Protocol                    string
+	FrontendIPConfigurationID   string
+	FrontendPortID              string
+	FrontendPort                int32
+	HostNames                   []string
+	SslCertificateID            string
+	SslCertificateName          string
+	RequireServerNameIndication bool
+	FirewallPolicyID            string
+}
+
+// ApplicationGatewayRoutingRule describes a request routing rule of an Application Gateway. A Basic rule sends traffic
+// to a backend pool with the given HTTP settings, while a PathBasedRouting rule refers to a URL path map instead.
+type ApplicationGatewayRoutingRule struct {
+	ID                      string
+	Name                    string
+	RuleType                string
+	Priority                int32
+	ListenerID              string
+	BackendPoolID           string
+	BackendHTTPSettingsID   string
+	URLPathMapID            string
+	RedirectConfigurationID string
+	RewriteRuleSetID        string
+}
+
+// ApplicationGatewayBackendHTTPSettings describes how an Application Gateway connects to its backend servers
+type ApplicationGatewayBackendHTTPSettings struct {
+	ID                             string
+	Name                           string
+	Port                           int32
+	Protocol                       string
+	CookieBasedAffinity            string
+	RequestTimeout                 int32
+	HostName                       string
+	PickHostNameFromBackendAddress bool
+	Path                           string
+	ProbeID                        string
+}
+
+// ApplicationGatewayBackendPool describes a backend pool of an Application Gateway. Addresses holds the IP addresses
+// and FQDNs of the pool, while NIC based members are listed by IP configuration.
+type ApplicationGatewayBackendPool struct {
+	ID                 string
+	Name               string
+	Addresses          []string
+	IPConfigurationIDs []string
+}
+
+// ApplicationGatewayBackendServerHealth describes the health of a single backend server as seen through one backend
+// HTTP settings of an Application Gateway
+type ApplicationGatewayBackendServerHealth struct {
+	BackendPoolID           string
+	BackendPoolName         string
+	BackendHTTPSettingsName string
+	Address                 string
+	Health                  string
+	HealthProbeLog          string
+}
+
+// WebApplicationFirewallPolicy describes an Azure WAF policy for Application Gateway
+type WebApplicationFirewallPolicy struct {
+	ID    string
+	Name  string
+	State string
+	Mode  string
+
+	ManagedRuleSets       []WebApplicationFirewallManagedRuleSet
+	ApplicationGatewayIDs []string
+}
+
+// WebApplicationFirewallManagedRuleSet describes a managed rule set of a WAF policy, e.g. OWASP 3.2
+type WebApplicationFirewallManagedRuleSet struct {
+	RuleSetType    string
+	RuleSetVersion string
+}
+
+// GetApplicationGatewaysClient is a helper function that will setup an Azure Application Gateway client on your behalf
+func GetApplicationGatewaysClient(subscriptionID string) (*network.ApplicationGatewaysClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create an Application Gateway client
+	appGatewayClient := network.NewApplicationGatewaysClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	appGatewayClient.Authorizer = *authorizer
+
+	return &appGatewayClient, nil
+}
+
+// GetWebApplicationFirewallPoliciesClient is a helper function that will setup an Azure WAF policy client on your behalf
+func GetWebApplicationFirewallPoliciesClient(subscriptionID string) (*network.WebApplicationFirewallPoliciesClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a WAF policy client
+	policyClient := network.NewWebApplicationFirewallPoliciesClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	policyClient.Authorizer = *authorizer
+
+	return &policyClient, nil
+}
+
+// GetApplicationGateway gets the details of an Azure Application Gateway by Name
+func GetApplicationGateway(t *testing.T, resGroupName string, appGatewayName string, subscriptionID string) ApplicationGateway {
+	appGateway, err := GetApplicationGatewayE(t, resGroupName, appGatewayName, subscriptionID)
+	require.NoError(t, err)
+
+	return appGateway
+}
+
+// GetApplicationGatewayE gets the details of an Azure Application Gateway by Name
+func GetApplicationGatewayE(t *testing.T, resGroupName string, appGatewayName string, subscriptionID string) (ApplicationGateway, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return ApplicationGateway{}, err
+	}
+
+	// Create an Application Gateway client
+	appGatewayClient, err := GetApplicationGatewaysClient(subscriptionID)
+	if err != nil {
+		return ApplicationGateway{}, err
+	}
+
+	// Get the details of the Application Gateway
+	appGateway, err := appGatewayClient.Get(context.Background(), resGroupName, appGatewayName)
+	if err != nil {
+		return ApplicationGateway{}, err
+	}
+
+	return newApplicationGateway(appGateway), nil
+}
+
+// GetWebApplicationFirewallPolicy gets the details of an Azure WAF policy by Name
+func GetWebApplicationFirewallPolicy(t *testing.T, resGroupName string, policyName string, subscriptionID string) WebApplicationFirewallPolicy {
+	policy, err := GetWebApplicationFirewallPolicyE(t, resGroupName, policyName, subscriptionID)
+	require.NoError(t, err)
+
+	return policy
+}
+
+// GetWebApplicationFirewallPolicyE gets the details of an Azure WAF policy by Name
+func GetWebApplicationFirewallPolicyE(t *testing.T, resGroupName string, policyName string, subscriptionID string) (WebApplicationFirewallPolicy, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return WebApplicationFirewallPolicy{}, err
+	}
+
+	// Create a WAF policy client
+	policyClient, err := GetWebApplicationFirewallPoliciesClient(subscriptionID)
+	if err != nil {
+		return WebApplicationFirewallPolicy{}, err
+	}
+
+	// Get the details of the WAF policy
+	policy, err := policyClient.Get(context.Background(), resGroupName, policyName)
+	if err != nil {
+		return WebApplicationFirewallPolicy{}, err
+	}
+
+	return newWebApplicationFirewallPolicy(policy), nil
+}
+
+// GetWebApplicationFirewallPolicyOfApplicationGateway gets the WAF policy associated with the given Application Gateway
+func GetWebApplicationFirewallPolicyOfApplicationGateway(t *testing.T, resGroupName string, appGatewayName string, subscriptionID string) WebApplicationFirewallPolicy {
+	policy, err := GetWebApplicationFirewallPolicyOfApplicationGatewayE(t, resGroupName, appGatewayName, subscriptionID)
+	require.NoError(t, err)
+
+	return policy
+}
+
+// GetWebApplicationFirewallPolicyOfApplicationGatewayE gets the WAF policy associated with the given Application Gateway
+func GetWebApplicationFirewallPolicyOfApplicationGatewayE(t *testing.T, resGroupName string, appGatewayName string, subscriptionID string) (WebApplicationFirewallPolicy, error) {
+	appGateway, err := GetApplicationGatewayE(t, resGroupName, appGatewayName, subscriptionID)
+	if err != nil {
+		return WebApplicationFirewallPolicy{}, err
+	}
+
+	if appGateway.FirewallPolicyID == "" {
+		return WebApplicationFirewallPolicy{}, ApplicationGatewayFirewallPolicyNotFound{AppGatewayName: appGatewayName}
+	}
+
+	// The policy may live in a different resource group from the gateway, so look it up by its ID
+	id, err := parseAzureResourceID(appGateway.FirewallPolicyID)
+	if err != nil {
+		return WebApplicationFirewallPolicy{}, err
+	}
+
+	return GetWebApplicationFirewallPolicyE(t, id.ResourceGroup, id.Name, id.SubscriptionID)
+}
+
+// GetApplicationGatewayBackendHealth gets the health of every backend server of the given Application Gateway
+func GetApplicationGatewayBackendHealth(t *testing.T, resGroupName string, appGatewayName string, subscriptionID string) []ApplicationGatewayBackendServerHealth {
+	health, err := GetApplicationGatewayBackendHealthE(t, resGroupName, appGatewayName, subscriptionID)
+	require.NoError(t, err)
+
+	return health
+}
+
+// GetApplicationGatewayBackendHealthE gets the health of every backend server of the given Application Gateway. The
+// backend health query is a long-running operation, so this waits for it to finish for up to
+// ApplicationGatewayBackendHealthTimeout.
+func GetApplicationGatewayBackendHealthE(t *testing.T, resGroupName string, appGatewayName string, subscriptionID string) ([]ApplicationGatewayBackendServerHealth, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create an Application Gateway client
+	appGatewayClient, err := GetApplicationGatewaysClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ApplicationGatewayBackendHealthTimeout)
+	defer cancel()
+
+	logger.Logf(t, "Querying backend health of Application Gateway %s", appGatewayName)
+
+	future, err := appGatewayClient.BackendHealth(ctx, resGroupName, appGatewayName, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForNetworkOperation(ctx, &future, appGatewayClient.Client, "Application Gateway backend health", ApplicationGatewayBackendHealthTimeout); err != nil {
+		return nil, err
+	}
+
+	health, err := future.Result(*appGatewayClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return newApplicationGatewayBackendHealth(health), nil
+}
+
+// AssertApplicationGatewayBackendsHealthy checks that the given Application Gateway has backend servers and that every
+// one of them passes its health probe
+func AssertApplicationGatewayBackendsHealthy(t *testing.T, resGroupName string, appGatewayName string, subscriptionID string) {
+	health := GetApplicationGatewayBackendHealth(t, resGroupName, appGatewayName, subscriptionID)
+	require.NotEmpty(t, health, "Check Application Gateway %s has backend servers", appGatewayName)
+
+	for _, server := range getUnhealthyBackendServers(health) {
+		assert.Fail(t, "Backend server is not healthy", "Server %s in pool %s reports %s through HTTP settings %s: %s",
+			server.Address, server.BackendPoolName, server.Health, server.BackendHTTPSettingsName, server.HealthProbeLog)
+	}
+}
+
+// getUnhealthyBackendServers returns the backend servers that do not report healthy
+func getUnhealthyBackendServers(health []ApplicationGatewayBackendServerHealth) []ApplicationGatewayBackendServerHealth {
+	unhealthy := []ApplicationGatewayBackendServerHealth{}
+
+	for _, server := range health {
+		if !strings.EqualFold(server.Health, ApplicationGatewayBackendHealthy) {
+			unhealthy = append(unhealthy, server)
+		}
+	}
+
+	return unhealthy
+}
+
+// newApplicationGateway converts an Application Gateway returned by the API into an ApplicationGateway
+func newApplicationGateway(appGateway network.ApplicationGateway) ApplicationGateway {
+	result := ApplicationGateway{
+		Zones:               []string{},
+		Listeners:           []ApplicationGatewayListener{},
+		RoutingRules:        []ApplicationGatewayRoutingRule{},
+		BackendHTTPSettings: []ApplicationGatewayBackendHTTPSettings{},
+		BackendPools:        []ApplicationGatewayBackendPool{},
+	}
+
+	if appGateway.ID != nil {
+		result.ID = *appGateway.ID
+	}
+	if appGateway.Name != nil {
+		result.Name = *appGateway.Name
+	}
+	if appGateway.Zones != nil {
+		result.Zones = *appGateway.Zones
+	}
+
+	props := appGateway.ApplicationGatewayPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.OperationalState = string(props.OperationalState)
+	result.FirewallPolicyID = getSubResourceID(props.FirewallPolicy)
+
+	if props.Sku != nil {
+		result.Sku = string(props.Sku.Name)
+		result.Tier = string(props.Sku.Tier)
+		if props.Sku.Capacity != nil {
+			result.Capacity = *props.Sku.Capacity
+		}
+	}
+	if props.AutoscaleConfiguration != nil {
+		if props.AutoscaleConfiguration.MinCapacity != nil {
+			result.MinCapacity = *props.AutoscaleConfiguration.MinCapacity
+		}
+		if props.AutoscaleConfiguration.MaxCapacity != nil {
+			result.MaxCapacity = *props.AutoscaleConfiguration.MaxCapacity
+		}
+	}
+	if props.EnableHTTP2 != nil {
+		result.EnableHTTP2 = *props.EnableHTTP2
+	}
+	if waf := props.WebApplicationFirewallConfiguration; waf != nil {
+		result.WebApplicationFirewall.Mode = string(waf.FirewallMode)
+		if waf.Enabled != nil {
+			result.WebApplicationFirewall.Enabled = *waf.Enabled
+		}
+		if waf.RuleSetType != nil {
+			result.WebApplicationFirewall.RuleSetType = *waf.RuleSetType
+		}
+		if waf.RuleSetVersion != nil {
+			result.WebApplicationFirewall.RuleSetVersion = *waf.RuleSetVersion
+		}
+	}
+
+	// Listeners refer to their frontend port by ID, so resolve the port numbers up front
+	frontendPorts := map[string]int32{}
+	if props.FrontendPorts != nil {
+		for _, port := range *props.FrontendPorts {
+			if port.ID != nil && port.ApplicationGatewayFrontendPortPropertiesFormat != nil && port.Port != nil {
+				frontendPorts[strings.ToLower(*port.ID)] = *port.Port
+			}
+		}
+	}
+
+	if props.HTTPListeners != nil {
+		for _, listener := range *props.HTTPListeners {
+			result.Listeners = append(result.Listeners, newApplicationGatewayListener(listener, frontendPorts))
+		}
+	}
+	if props.RequestRoutingRules != nil {
+		for _, rule := range *props.RequestRoutingRules {
+			result.RoutingRules = append(result.RoutingRules, newApplicationGatewayRoutingRule(rule))
+		}
+	}
+	if props.BackendHTTPSettingsCollection != nil {
+		for _, settings := range *props.BackendHTTPSettingsCollection {
+			result.BackendHTTPSettings = append(result.BackendHTTPSettings, newApplicationGatewayBackendHTTPSettings(settings))
+		}
+	}
+	if props.BackendAddressPools != nil {
+		for _, pool := range *props.BackendAddressPools {
+			result.BackendPools = append(result.BackendPools, newApplicationGatewayBackendPool(pool))
+		}
+	}
+
+	return result
+}
+
+// newApplicationGatewayListener converts an HTTP listener returned by the API into an ApplicationGatewayListener
+func newApplicationGatewayListener(listener network.ApplicationGatewayHTTPListener, frontendPorts map[string]int32) ApplicationGatewayListener {
+	result := ApplicationGatewayListener{HostNames: []string{}}
+
+	if listener.ID != nil {
+		result.ID = *listener.ID
+	}
+	if listener.Name != nil {
+		result.Name = *listener.Name
+	}
+
+	props := listener.ApplicationGatewayHTTPListenerPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.Protocol = string(props.Protocol)
+	result.FrontendIPConfigurationID = getSubResourceID(props.FrontendIPConfiguration)
+	result.FrontendPortID = getSubResourceID(props.FrontendPort)
+	result.FrontendPort = frontendPorts[strings.ToLower(result.FrontendPortID)]
+	result.SslCertificateID = getSubResourceID(props.SslCertificate)
+	result.FirewallPolicyID = getSubResourceID(props.FirewallPolicy)
+
+	// A listener has either a single host name or, for multi-site listeners, a list of them
+	result.HostNames = mergeRuleValues(props.HostName, props.HostNames)
+
+	if result.SslCertificateID != "" {
+		if id, err := parseAzureResourceID(result.SslCertificateID); err == nil {
+			result.SslCertificateName = id.Name
+		}
+	}
+	if props.RequireServerNameIndication != nil {
+		result.RequireServerNameIndication = *props.RequireServerNameIndication
+	}
+
+	return result
+}
+
+// newApplicationGatewayRoutingRule converts a request routing rule returned by the API into an
+// ApplicationGatewayRoutingRule
+func newApplicationGatewayRoutingRule(rule network.ApplicationGatewayRequestRoutingRule) ApplicationGatewayRoutingRule {
+	result := ApplicationGatewayRoutingRule{}
+
+	if rule.ID != nil {
+		result.ID = *rule.ID
+	}
+	if rule.Name != nil {
+		result.Name = *rule.Name
+	}
+
+	props := rule.ApplicationGatewayRequestRoutingRulePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.RuleType = string(props.RuleType)
+	result.ListenerID = getSubResourceID(props.HTTPListener)
+	result.BackendPoolID = getSubResourceID(props.BackendAddressPool)
+	result.BackendHTTPSettingsID = getSubResourceID(props.BackendHTTPSettings)
+	result.URLPathMapID = getSubResourceID(props.URLPathMap)
+	result.RedirectConfigurationID = getSubResourceID(props.RedirectConfiguration)
+	result.RewriteRuleSetID = getSubResourceID(props.RewriteRuleSet)
+
+	if props.Priority != nil {
+		result.Priority = *props.Priority
+	}
+
+	return result
+}
+
+// newApplicationGatewayBackendHTTPSettings converts backend HTTP settings returned by the API into
+// ApplicationGatewayBackendHTTPSettings
+func newApplicationGatewayBackendHTTPSettings(settings network.ApplicationGatewayBackendHTTPSettings) ApplicationGatewayBackendHTTPSettings {
+	result := ApplicationGatewayBackendHTTPSettings{}
+
+	if settings.ID != nil {
+		result.ID = *settings.ID
+	}
+	if settings.Name != nil {
+		result.Name = *settings.Name
+	}
+
+	props := settings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.Protocol = string(props.Protocol)
+	result.CookieBasedAffinity = string(props.CookieBasedAffinity)
+	result.ProbeID = getSubResourceID(props.Probe)
+
+	if props.Port != nil {
+		result.Port = *props.Port
+	}
+	if props.RequestTimeout != nil {
+		result.RequestTimeout = *props.RequestTimeout
+	}
+	if props.HostName != nil {
+		result.HostName = *props.HostName
+	}
+	if props.PickHostNameFromBackendAddress != nil {
+		result.PickHostNameFromBackendAddress = *props.PickHostNameFromBackendAddress
+	}
+	if props.Path != nil {
+		result.Path = *props.Path
+	}
+
+	return result
+}
+
+// newApplicationGatewayBackendPool converts a backend address pool returned by the API into an
+// ApplicationGatewayBackendPool
+func newApplicationGatewayBackendPool(pool network.ApplicationGatewayBackendAddressPool) ApplicationGatewayBackendPool {
+	result := ApplicationGatewayBackendPool{
+		Addresses:          []string{},
+		IPConfigurationIDs: []string{},
+	}
+
+	if pool.ID != nil {
+		result.ID = *pool.ID
+	}
+	if pool.Name != nil {
+		result.Name = *pool.Name
+	}
+
+	props := pool.ApplicationGatewayBackendAddressPoolPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	if props.BackendAddresses != nil {
+		for _, address := range *props.BackendAddresses {
+			if address.IPAddress != nil {
+				result.Addresses = append(result.Addresses, *address.IPAddress)
+			}
+			if address.Fqdn != nil {
+				result.Addresses = append(result.Addresses, *address.Fqdn)
+			}
+		}
+	}
+	if props.BackendIPConfigurations != nil {
+		for _, ipConfig := range *props.BackendIPConfigurations {
+			if ipConfig.ID != nil {
+				result.IPConfigurationIDs = append(result.IPConfigurationIDs, *ipConfig.ID)
+			}
+		}
+	}
+
+	return result
+}
+
+// newApplicationGatewayBackendHealth flattens the backend health returned by the API into one entry per server and
+// backend HTTP settings
+func newApplicationGatewayBackendHealth(health network.ApplicationGatewayBackendHealth) []ApplicationGatewayBackendServerHealth {
+	result := []ApplicationGatewayBackendServerHealth{}
+
+	if health.BackendAddressPools == nil {
+		return result
+	}
+
+	for _, pool := range *health.BackendAddressPools {
+		var poolID, poolName string
+		if pool.BackendAddressPool != nil {
+			if pool.BackendAddressPool.ID != nil {
+				poolID = *pool.BackendAddressPool.ID
+			}
+			if pool.BackendAddressPool.Name != nil {
+				poolName = *pool.BackendAddressPool.Name
+			}
+		}
+		// The health response references the pool by ID only
+		if poolName == "" && poolID != "" {
+			if id, err := parseAzureResourceID(poolID); err == nil {
+				poolName = id.Name
+			}
+		}
+
+		if pool.BackendHTTPSettingsCollection == nil {
+			continue
+		}
+
+		for _, settings := range *pool.BackendHTTPSettingsCollection {
+			var settingsName string
+			if settings.BackendHTTPSettings != nil {
+				if settings.BackendHTTPSettings.Name != nil {
+					settingsName = *settings.BackendHTTPSettings.Name
+				} else if settings.BackendHTTPSettings.ID != nil {
+					if id, err := parseAzureResourceID(*settings.BackendHTTPSettings.ID); err == nil {
+						settingsName = id.Name
+					}
+				}
+			}
+
+			if settings.Servers == nil {
+				continue
+			}
+
+			for _, server := range *settings.Servers {
+				serverHealth := ApplicationGatewayBackendServerHealth{
+					BackendPoolID:           poolID,
+					BackendPoolName:         poolName,
+					BackendHTTPSettingsName: settingsName,
+					Health:                  string(server.Health),
+				}
+				if server.Address != nil {
+					serverHealth.Address = *server.Address
+				}
+				if server.HealthProbeLog != nil {
+					serverHealth.HealthProbeLog = *server.HealthProbeLog
+				}
+
+				result = append(result, serverHealth)
+			}
+		}
+	}
+
+	return result
+}
+
+// newWebApplicationFirewallPolicy converts a WAF policy returned by the API into a WebApplicationFirewallPolicy
+func newWebApplicationFirewallPolicy(policy network.WebApplicationFirewallPolicy) WebApplicationFirewallPolicy {
+	result := WebApplicationFirewallPolicy{
+		ManagedRuleSets:       []WebApplicationFirewallManagedRuleSet{},
+		ApplicationGatewayIDs: []string{},
+	}
+
+	if policy.ID != nil {
+		result.ID = *policy.ID
+	}
+	if policy.Name != nil {
+		result.Name = *policy.Name
+	}
+
+	props := policy.WebApplicationFirewallPolicyPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	if props.PolicySettings != nil {
+		result.State = string(props.PolicySettings.State)
+		result.Mode = string(props.PolicySettings.Mode)
+	}
+	if props.ManagedRules != nil && props.ManagedRules.ManagedRuleSets != nil {
+		for _, ruleSet := range *props.ManagedRules.ManagedRuleSets {
+			managedRuleSet := WebApplicationFirewallManagedRuleSet{}
+			if ruleSet.RuleSetType != nil {
+				managedRuleSet.RuleSetType = *ruleSet.RuleSetType
+			}
+			if ruleSet.RuleSetVersion != nil {
+				managedRuleSet.RuleSetVersion = *ruleSet.RuleSetVersion
+			}
+			result.ManagedRuleSets = append(result.ManagedRuleSets, managedRuleSet)
+		}
+	}
+	if props.ApplicationGateways != nil {
+		for _, appGateway := range *props.ApplicationGateways {
+			if appGateway.ID != nil {
+				result.ApplicationGatewayIDs = append(result.ApplicationGatewayIDs, *appGateway.ID)
+			}
+		}
+	}
+
+	return result
+}
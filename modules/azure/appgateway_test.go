@@ -0,0 +1,85 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewApplicationGatewayListener(t *testing.T) {
+	t.Parallel()
+
+	portID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/agw/frontendPorts/port-443"
+	certID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/agw/sslCertificates/wildcard"
+	hostNames := []string{"www.example.com", "api.example.com"}
+
+	listener := newApplicationGatewayListener(network.ApplicationGatewayHTTPListener{
+		ApplicationGatewayHTTPListenerPropertiesFormat: &network.ApplicationGatewayHTTPListenerPropertiesFormat{
+			Protocol:       network.HTTPS,
+			FrontendPort:   &network.SubResource{ID: &portID},
+			SslCertificate: &network.SubResource{ID: &certID},
+			HostNames:      &hostNames,
+		},
+	}, map[string]int32{"/subscriptions/sub/resourcegroups/rg/providers/microsoft.network/applicationgateways/agw/frontendports/port-443": 443})
+
+	require.Equal(t, int32(443), listener.FrontendPort)
+	require.Equal(t, "Https", listener.Protocol)
+	require.Equal(t, "wildcard", listener.SslCertificateName)
+	require.Equal(t, hostNames, listener.HostNames)
+}
+
+func TestNewApplicationGatewayBackendHealth(t *testing.T) {
+	t.Parallel()
+
+	poolID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/agw/backendAddressPools/web-pool"
+	settingsName := "web-settings"
+	healthyAddress := "10.0.1.4"
+	downAddress := "10.0.1.5"
+	probeLog := "Received invalid status code: 502 in the backend server's HTTP response."
+
+	health := newApplicationGatewayBackendHealth(network.ApplicationGatewayBackendHealth{
+		BackendAddressPools: &[]network.ApplicationGatewayBackendHealthPool{{
+			BackendAddressPool: &network.ApplicationGatewayBackendAddressPool{ID: &poolID},
+			BackendHTTPSettingsCollection: &[]network.ApplicationGatewayBackendHealthHTTPSettings{{
+				BackendHTTPSettings: &network.ApplicationGatewayBackendHTTPSettings{Name: &settingsName},
+				Servers: &[]network.ApplicationGatewayBackendHealthServer{
+					{Address: &healthyAddress, Health: network.Up},
+					{Address: &downAddress, Health: network.Down, HealthProbeLog: &probeLog},
+				},
+			}},
+		}},
+	})
+
+	require.Len(t, health, 2)
+	require.Equal(t, "web-pool", health[0].BackendPoolName)
+	require.Equal(t, "web-settings", health[0].BackendHTTPSettingsName)
+	require.Equal(t, []ApplicationGatewayBackendServerHealth{health[1]}, getUnhealthyBackendServers(health))
+}
+
+func TestGetApplicationGatewayE(t *testing.T) {
+	t.Parallel()
+
+	resGroupName := ""
+	appGatewayName := ""
+	subscriptionID := ""
+
+	_, err := GetApplicationGatewayE(t, resGroupName, appGatewayName, subscriptionID)
+	require.Error(t, err)
+}
+
+func TestGetApplicationGatewayBackendHealthE(t *testing.T) {
+	t.Parallel()
+
+	resGroupName := ""
+	appGatewayName := ""
+	subscriptionID := ""
+
+	_, err := GetApplicationGatewayBackendHealthE(t, resGroupName, appGatewayName, subscriptionID)
+	require.Error(t, err)
+}
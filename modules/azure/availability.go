@@ -0,0 +1,332 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// VMPlacement describes where Azure placed a Virtual Machine
+type VMPlacement struct {
+	VMName string
+	Zones  []string
+
+	// FaultDomain and UpdateDomain are the platform domains the Virtual Machine landed in. They are only
+	// meaningful for Virtual Machines in an availability set, and HasFaultDomain is false when the instance view
+	// does not report a fault domain, e.g. for a deallocated Virtual Machine.
+	FaultDomain    int32
+	UpdateDomain   int32
+	HasFaultDomain bool
+}
+
+// AvailabilitySet describes an availability set and the domain each of its member Virtual Machines landed in
+type AvailabilitySet struct {
+	ID                string
+	Name              string
+	FaultDomainCount  int32
+	UpdateDomainCount int32
+	Members           []VMPlacement
+
+	// ProximityPlacementGroupID is the ID of the proximity placement group the set belongs to, if any
+	ProximityPlacementGroupID string
+}
+
+// GetAvailabilitySetsClient is a helper function that will setup an Azure Availability Set client on your behalf
+func GetAvailabilitySetsClient(subscriptionID string) (*compute.AvailabilitySetsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create an Availability Set client
+	avSetClient := compute.NewAvailabilitySetsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	avSetClient.Authorizer = *authorizer
+
+	return &avSetClient, nil
+}
+
+// GetZonesOfVirtualMachine gets the availability zones of the given Virtual Machine
+func GetZonesOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) []string {
+	zones, err := GetZonesOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return zones
+}
+
+// GetZonesOfVirtualMachineE gets the availability zones of the given Virtual Machine
+func GetZonesOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) ([]string, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := []string{}
+	if vm.Zones != nil {
+		zones = append(zones, *vm.Zones...)
+	}
+
+	return zones, nil
+}
+
+// GetPlacementOfVirtualMachine gets the zones, fault domain and update domain of the given Virtual Machine
+func GetPlacementOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) VMPlacement {
+	placement, err := GetPlacementOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return placement
+}
+
+// GetPlacementOfVirtualMachineE gets the zones, fault domain and update domain of the given Virtual Machine
+func GetPlacementOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (VMPlacement, error) {
+	placement := VMPlacement{VMName: vmName, Zones: []string{}}
+
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return placement, err
+	}
+
+	// Create a VM client
+	vmClient, err := GetVirtualMachineClient(subscriptionID)
+	if err != nil {
+		return placement, err
+	}
+
+	// Get the details of the target virtual machine, including the domains from its instance view
+	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceViewTypesInstanceView)
+	if err != nil {
+		return placement, err
+	}
+
+	return newVMPlacement(vmName, vm), nil
+}
+
+// GetAvailabilitySet gets the domain counts of an availability set and the domain each member Virtual Machine landed in
+func GetAvailabilitySet(t *testing.T, resGroupName string, avSetName string, subscriptionID string) AvailabilitySet {
+	avSet, err := GetAvailabilitySetE(t, resGroupName, avSetName, subscriptionID)
+	require.NoError(t, err)
+
+	return avSet
+}
+
+// GetAvailabilitySetE gets the domain counts of an availability set and the domain each member Virtual Machine landed in
+func GetAvailabilitySetE(t *testing.T, resGroupName string, avSetName string, subscriptionID string) (AvailabilitySet, error) {
+	avSet := AvailabilitySet{Members: []VMPlacement{}}
+
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return avSet, err
+	}
+
+	// Create an Availability Set client
+	avSetClient, err := GetAvailabilitySetsClient(subscriptionID)
+	if err != nil {
+		return avSet, err
+	}
+
+	// Get the details of the availability set
+	set, err := avSetClient.Get(context.Background(), resGroupName, avSetName)
+	if err != nil {
+		return avSet, err
+	}
+
+	avSet = newAvailabilitySet(set)
+
+	// Look up the domains each member Virtual Machine landed in
+	for _, memberID := range getAvailabilitySetMemberIDs(set) {
+		id, err := parseAzureResourceID(memberID)
+		if err != nil {
+			return avSet, err
+		}
+
+		placement, err := GetPlacementOfVirtualMachineE(t, id.ResourceGroup, id.Name, subscriptionID)
+		if err != nil {
+			return avSet, err
+		}
+		avSet.Members = append(avSet.Members, placement)
+	}
+
+	return avSet, nil
+}
+
+// GetAvailabilitySetOfVirtualMachine gets the availability set the given Virtual Machine belongs to. An empty
+// AvailabilitySet is returned if the Virtual Machine is not part of one.
+func GetAvailabilitySetOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) AvailabilitySet {
+	avSet, err := GetAvailabilitySetOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return avSet
+}
+
+// GetAvailabilitySetOfVirtualMachineE gets the availability set the given Virtual Machine belongs to. An empty
+// AvailabilitySet is returned if the Virtual Machine is not part of one.
+func GetAvailabilitySetOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (AvailabilitySet, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return AvailabilitySet{}, err
+	}
+
+	if vm.VirtualMachineProperties == nil || vm.AvailabilitySet == nil || vm.AvailabilitySet.ID == nil {
+		return AvailabilitySet{Members: []VMPlacement{}}, nil
+	}
+
+	id, err := parseAzureResourceID(*vm.AvailabilitySet.ID)
+	if err != nil {
+		return AvailabilitySet{}, err
+	}
+
+	return GetAvailabilitySetE(t, id.ResourceGroup, id.Name, subscriptionID)
+}
+
+// GetProximityPlacementGroupOfVirtualMachine gets the ID of the proximity placement group of the given Virtual Machine
+func GetProximityPlacementGroupOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) string {
+	ppgID, err := GetProximityPlacementGroupOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return ppgID
+}
+
+// GetProximityPlacementGroupOfVirtualMachineE gets the ID of the proximity placement group of the given Virtual Machine
+func GetProximityPlacementGroupOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (string, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	if vm.VirtualMachineProperties == nil || vm.ProximityPlacementGroup == nil || vm.ProximityPlacementGroup.ID == nil {
+		return "", nil
+	}
+
+	return *vm.ProximityPlacementGroup.ID, nil
+}
+
+// AssertVirtualMachinesSpreadAcrossZones checks that the given Virtual Machines are spread across at least minZones availability zones
+func AssertVirtualMachinesSpreadAcrossZones(t *testing.T, resGroupName string, vmNames []string, minZones int, subscriptionID string) {
+	placements := getPlacementsOfVirtualMachines(t, resGroupName, vmNames, subscriptionID)
+
+	zones := countDistinctZones(placements)
+	assert.True(t, zones >= minZones, "Virtual Machines %v are spread across %d zones, expected at least %d", vmNames, zones, minZones)
+}
+
+// AssertVirtualMachinesSpreadAcrossFaultDomains checks that the given Virtual Machines are spread across at least
+// minFaultDomains platform fault domains
+func AssertVirtualMachinesSpreadAcrossFaultDomains(t *testing.T, resGroupName string, vmNames []string, minFaultDomains int, subscriptionID string) {
+	placements := getPlacementsOfVirtualMachines(t, resGroupName, vmNames, subscriptionID)
+
+	faultDomains := countDistinctFaultDomains(placements)
+	assert.True(t, faultDomains >= minFaultDomains, "Virtual Machines %v are spread across %d fault domains, expected at least %d", vmNames, faultDomains, minFaultDomains)
+}
+
+// getPlacementsOfVirtualMachines looks up the placement of each of the given Virtual Machines
+func getPlacementsOfVirtualMachines(t *testing.T, resGroupName string, vmNames []string, subscriptionID string) []VMPlacement {
+	placements := []VMPlacement{}
+	for _, vmName := range vmNames {
+		placements = append(placements, GetPlacementOfVirtualMachine(t, resGroupName, vmName, subscriptionID))
+	}
+
+	return placements
+}
+
+// countDistinctZones counts the distinct availability zones across the given placements
+func countDistinctZones(placements []VMPlacement) int {
+	zones := make(map[string]bool)
+	for _, placement := range placements {
+		for _, zone := range placement.Zones {
+			zones[zone] = true
+		}
+	}
+
+	return len(zones)
+}
+
+// countDistinctFaultDomains counts the distinct fault domains across the given placements, skipping placements
+// without a known fault domain
+func countDistinctFaultDomains(placements []VMPlacement) int {
+	faultDomains := make(map[int32]bool)
+	for _, placement := range placements {
+		if placement.HasFaultDomain {
+			faultDomains[placement.FaultDomain] = true
+		}
+	}
+
+	return len(faultDomains)
+}
+
+// newVMPlacement reads the zones and platform domains of a Virtual Machine returned by the API with its instance view
+func newVMPlacement(vmName string, vm compute.VirtualMachine) VMPlacement {
+	placement := VMPlacement{VMName: vmName, Zones: []string{}}
+
+	if vm.Zones != nil {
+		placement.Zones = append(placement.Zones, *vm.Zones...)
+	}
+	if vm.VirtualMachineProperties != nil && vm.InstanceView != nil {
+		if vm.InstanceView.PlatformFaultDomain != nil {
+			placement.FaultDomain = *vm.InstanceView.PlatformFaultDomain
+			placement.HasFaultDomain = true
+		}
+		if vm.InstanceView.PlatformUpdateDomain != nil {
+			placement.UpdateDomain = *vm.InstanceView.PlatformUpdateDomain
+		}
+	}
+
+	return placement
+}
+
+// newAvailabilitySet converts an availability set returned by the API into an AvailabilitySet. The members are looked
+// up separately, see getAvailabilitySetMemberIDs.
+func newAvailabilitySet(set compute.AvailabilitySet) AvailabilitySet {
+	avSet := AvailabilitySet{Members: []VMPlacement{}}
+
+	if set.ID != nil {
+		avSet.ID = *set.ID
+	}
+	if set.Name != nil {
+		avSet.Name = *set.Name
+	}
+
+	props := set.AvailabilitySetProperties
+	if props == nil {
+		return avSet
+	}
+
+	if props.PlatformFaultDomainCount != nil {
+		avSet.FaultDomainCount = *props.PlatformFaultDomainCount
+	}
+	if props.PlatformUpdateDomainCount != nil {
+		avSet.UpdateDomainCount = *props.PlatformUpdateDomainCount
+	}
+	if props.ProximityPlacementGroup != nil && props.ProximityPlacementGroup.ID != nil {
+		avSet.ProximityPlacementGroupID = *props.ProximityPlacementGroup.ID
+	}
+
+	return avSet
+}
+
+// getAvailabilitySetMemberIDs returns the IDs of the Virtual Machines in an availability set returned by the API
+func getAvailabilitySetMemberIDs(set compute.AvailabilitySet) []string {
+	ids := []string{}
+
+	if set.AvailabilitySetProperties == nil || set.VirtualMachines == nil {
+		return ids
+	}
+	for _, member := range *set.VirtualMachines {
+		if member.ID != nil {
+			ids = append(ids, *member.ID)
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,126 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountDistinctFaultDomains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		placements []VMPlacement
+		want       int
+	}{
+		{name: "noPlacements", placements: []VMPlacement{}, want: 0},
+		{
+			name:       "spreadAcrossDomains",
+			placements: []VMPlacement{{FaultDomain: 0, HasFaultDomain: true}, {FaultDomain: 1, HasFaultDomain: true}, {FaultDomain: 1, HasFaultDomain: true}},
+			want:       2,
+		},
+		{
+			name:       "unknownFaultDomainIsSkipped",
+			placements: []VMPlacement{{FaultDomain: 1, HasFaultDomain: true}, {VMName: "deallocated"}},
+			want:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, countDistinctFaultDomains(tt.placements))
+		})
+	}
+}
+
+func TestNewVMPlacement(t *testing.T) {
+	t.Parallel()
+
+	faultDomain := int32(0)
+	updateDomain := int32(3)
+
+	placement := newVMPlacement("vm", compute.VirtualMachine{
+		Zones: &[]string{"2"},
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			InstanceView: &compute.VirtualMachineInstanceView{PlatformFaultDomain: &faultDomain, PlatformUpdateDomain: &updateDomain},
+		},
+	})
+	require.Equal(t, VMPlacement{VMName: "vm", Zones: []string{"2"}, FaultDomain: 0, UpdateDomain: 3, HasFaultDomain: true}, placement)
+
+	require.False(t, newVMPlacement("vm", compute.VirtualMachine{}).HasFaultDomain)
+}
+
+func TestNewAvailabilitySet(t *testing.T) {
+	t.Parallel()
+
+	id := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/availabilitySets/web"
+	name := "web"
+	ppgID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/proximityPlacementGroups/ppg"
+	vmID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/web-0"
+	faultDomains := int32(2)
+	updateDomains := int32(5)
+
+	set := compute.AvailabilitySet{
+		ID:   &id,
+		Name: &name,
+		AvailabilitySetProperties: &compute.AvailabilitySetProperties{
+			PlatformFaultDomainCount:  &faultDomains,
+			PlatformUpdateDomainCount: &updateDomains,
+			ProximityPlacementGroup:   &compute.SubResource{ID: &ppgID},
+			VirtualMachines:           &[]compute.SubResource{{ID: &vmID}, {}},
+		},
+	}
+
+	require.Equal(t, AvailabilitySet{
+		ID:                        id,
+		Name:                      name,
+		FaultDomainCount:          2,
+		UpdateDomainCount:         5,
+		Members:                   []VMPlacement{},
+		ProximityPlacementGroupID: ppgID,
+	}, newAvailabilitySet(set))
+	require.Equal(t, []string{vmID}, getAvailabilitySetMemberIDs(set))
+}
+
+func TestGetPlacementOfVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := GetPlacementOfVirtualMachineE(t, rgName, vmName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetAvailabilitySetE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	avSetName := ""
+	subID := ""
+
+	_, err := GetAvailabilitySetE(t, rgName, avSetName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetAvailabilitySetOfVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := GetAvailabilitySetOfVirtualMachineE(t, rgName, vmName, subID)
+
+	require.Error(t, err)
+}
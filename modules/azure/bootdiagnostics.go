@@ -0,0 +1,138 @@
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bootDiagnosticsSasExpiryMinutes is how long the SAS URIs returned by the boot diagnostics data API stay valid
+const bootDiagnosticsSasExpiryMinutes int32 = 60
+
+// SerialConsoleLogDownloadTimeout is how long to wait for the serial console log to download from its SAS URI
+const SerialConsoleLogDownloadTimeout = 2 * time.Minute
+
+// BootDiagnosticsData holds the SAS URIs of the serial console log and screenshot of a Virtual Machine
+type BootDiagnosticsData struct {
+	SerialConsoleLogURI  string
+	ConsoleScreenshotURI string
+}
+
+// GetBootDiagnosticsDataOfVirtualMachine gets the serial console log and screenshot URIs of the given Virtual Machine
+func GetBootDiagnosticsDataOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) BootDiagnosticsData {
+	data, err := GetBootDiagnosticsDataOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return data
+}
+
+// GetBootDiagnosticsDataOfVirtualMachineE gets the serial console log and screenshot URIs of the given Virtual Machine
+func GetBootDiagnosticsDataOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (BootDiagnosticsData, error) {
+	data := BootDiagnosticsData{}
+
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return data, err
+	}
+
+	// Create a VM client
+	vmClient, err := GetVirtualMachineClient(subscriptionID)
+	if err != nil {
+		return data, err
+	}
+
+	// Retrieve SAS URIs for the boot diagnostics blobs
+	expiry := bootDiagnosticsSasExpiryMinutes
+	result, err := vmClient.RetrieveBootDiagnosticsData(context.Background(), resGroupName, vmName, &expiry)
+	if err != nil {
+		return data, err
+	}
+
+	if result.SerialConsoleLogBlobURI != nil {
+		data.SerialConsoleLogURI = *result.SerialConsoleLogBlobURI
+	}
+	if result.ConsoleScreenshotBlobURI != nil {
+		data.ConsoleScreenshotURI = *result.ConsoleScreenshotBlobURI
+	}
+
+	return data, nil
+}
+
+// GetSerialConsoleLogOfVirtualMachine downloads the serial console log of the given Virtual Machine
+func GetSerialConsoleLogOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) string {
+	log, err := GetSerialConsoleLogOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return log
+}
+
+// GetSerialConsoleLogOfVirtualMachineE downloads the serial console log of the given Virtual Machine
+func GetSerialConsoleLogOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (string, error) {
+	data, err := GetBootDiagnosticsDataOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	if data.SerialConsoleLogURI == "" {
+		return "", BootDiagnosticsNotAvailable{VMName: vmName}
+	}
+
+	return downloadSerialConsoleLog(vmName, data.SerialConsoleLogURI, SerialConsoleLogDownloadTimeout)
+}
+
+// downloadSerialConsoleLog downloads the serial console log of a Virtual Machine through its SAS URI
+func downloadSerialConsoleLog(vmName string, logURI string, timeout time.Duration) (string, error) {
+	client := http.Client{Timeout: timeout}
+
+	resp, err := client.Get(logURI)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", BootDiagnosticsDownloadFailed{VMName: vmName, StatusCode: resp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// AssertSerialConsoleLogContains checks that the serial console log of the given Virtual Machine contains the expected
+// text, e.g. "Cloud-init v. 19.4 finished". The log is attached to the test output if the check fails.
+func AssertSerialConsoleLogContains(t *testing.T, resGroupName string, vmName string, expected string, subscriptionID string) {
+	log := GetSerialConsoleLogOfVirtualMachine(t, resGroupName, vmName, subscriptionID)
+
+	if !assert.Contains(t, log, expected, "Check serial console log of Virtual Machine %s", vmName) {
+		logger.Logf(t, "Serial console log of Virtual Machine %s:\n%s", vmName, log)
+	}
+}
+
+// LogSerialConsoleOnFailure attaches the serial console log of the given Virtual Machine to the test output if the test
+// has failed. It is meant to be deferred right after the Virtual Machine is created:
+//
+//	defer azure.LogSerialConsoleOnFailure(t, resGroupName, vmName, "")
+func LogSerialConsoleOnFailure(t *testing.T, resGroupName string, vmName string, subscriptionID string) {
+	if !t.Failed() {
+		return
+	}
+
+	log, err := GetSerialConsoleLogOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		logger.Logf(t, "Could not retrieve serial console log of Virtual Machine %s: %v", vmName, err)
+		return
+	}
+
+	logger.Logf(t, "Serial console log of Virtual Machine %s:\n%s", vmName, log)
+}
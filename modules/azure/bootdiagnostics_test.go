@@ -0,0 +1,82 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadSerialConsoleLog(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Cloud-init v. 19.4 finished")
+	}))
+	defer server.Close()
+
+	log, err := downloadSerialConsoleLog("vm", server.URL, time.Minute)
+
+	require.NoError(t, err)
+	require.Equal(t, "Cloud-init v. 19.4 finished", log)
+}
+
+func TestDownloadSerialConsoleLogFailedStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := downloadSerialConsoleLog("vm", server.URL, time.Minute)
+
+	require.Equal(t, BootDiagnosticsDownloadFailed{VMName: "vm", StatusCode: http.StatusForbidden}, err)
+}
+
+func TestDownloadSerialConsoleLogTimeout(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	_, err := downloadSerialConsoleLog("vm", server.URL, 10*time.Millisecond)
+
+	require.Error(t, err)
+}
+
+func TestGetBootDiagnosticsDataOfVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := GetBootDiagnosticsDataOfVirtualMachineE(t, rgName, vmName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetSerialConsoleLogOfVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := GetSerialConsoleLogOfVirtualMachineE(t, rgName, vmName, subID)
+
+	require.Error(t, err)
+}
@@ -0,0 +1,284 @@
+package azure
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AzureReservedIPsPerSubnet is the number of addresses Azure reserves in every subnet: the network address, the
+// default gateway, two addresses for Azure DNS and the broadcast address
+const AzureReservedIPsPerSubnet = 5
+
+// CIDROverlap is a pair of overlapping address prefixes
+type CIDROverlap struct {
+	PrefixA string
+	PrefixB string
+}
+
+func (overlap CIDROverlap) String() string {
+	return fmt.Sprintf("%s overlaps %s", overlap.PrefixA, overlap.PrefixB)
+}
+
+// subnetAddressPrefix is a single address prefix of a subnet
+type subnetAddressPrefix struct {
+	SubnetName string
+	Prefix     string
+}
+
+// CIDRContains checks whether the inner prefix lies entirely within the outer prefix
+func CIDRContains(outer string, inner string) (bool, error) {
+	outerNet, err := parseCIDR(outer)
+	if err != nil {
+		return false, err
+	}
+	innerNet, err := parseCIDR(inner)
+	if err != nil {
+		return false, err
+	}
+
+	outerLength, outerBits := outerNet.Mask.Size()
+	innerLength, innerBits := innerNet.Mask.Size()
+
+	return outerBits == innerBits && outerLength <= innerLength && outerNet.Contains(innerNet.IP), nil
+}
+
+// CIDRsOverlap checks whether two prefixes share any address
+func CIDRsOverlap(prefixA string, prefixB string) (bool, error) {
+	netA, err := parseCIDR(prefixA)
+	if err != nil {
+		return false, err
+	}
+	netB, err := parseCIDR(prefixB)
+	if err != nil {
+		return false, err
+	}
+
+	// Two prefixes overlap exactly when one contains the network address of the other
+	return netA.Contains(netB.IP) || netB.Contains(netA.IP), nil
+}
+
+// GetUsableIPCount returns the number of addresses of an IPv4 prefix that can be assigned to resources, i.e. the size
+// of the prefix less the addresses Azure reserves
+func GetUsableIPCount(prefix string) (int64, error) {
+	ipNet, err := parseCIDR(prefix)
+	if err != nil {
+		return 0, err
+	}
+	if ipNet.IP.To4() == nil {
+		return 0, CIDRNotValid{CIDR: prefix, Reason: "usable IP counts are only supported for IPv4 prefixes"}
+	}
+
+	length, bits := ipNet.Mask.Size()
+	usable := int64(1)<<uint(bits-length) - AzureReservedIPsPerSubnet
+	if usable < 0 {
+		return 0, nil
+	}
+
+	return usable, nil
+}
+
+// FindPrefixesOutsideAddressSpace returns the prefixes that do not lie entirely within one of the address space
+// prefixes, e.g. subnets outside the address space of their VNet
+func FindPrefixesOutsideAddressSpace(addressSpace []string, prefixes []string) ([]string, error) {
+	outside := []string{}
+
+	for _, prefix := range prefixes {
+		contained := false
+		for _, space := range addressSpace {
+			inside, err := CIDRContains(space, prefix)
+			if err != nil {
+				return nil, err
+			}
+			if inside {
+				contained = true
+				break
+			}
+		}
+
+		if !contained {
+			outside = append(outside, prefix)
+		}
+	}
+
+	return outside, nil
+}
+
+// FindOverlappingPrefixes returns every pair of prefixes in the list that overlap, e.g. overlapping subnets of a VNet
+func FindOverlappingPrefixes(prefixes []string) ([]CIDROverlap, error) {
+	overlaps := []CIDROverlap{}
+
+	for i := range prefixes {
+		for j := i + 1; j < len(prefixes); j++ {
+			overlap, err := CIDRsOverlap(prefixes[i], prefixes[j])
+			if err != nil {
+				return nil, err
+			}
+			if overlap {
+				overlaps = append(overlaps, CIDROverlap{PrefixA: prefixes[i], PrefixB: prefixes[j]})
+			}
+		}
+	}
+
+	return overlaps, nil
+}
+
+// FindAddressSpaceOverlaps returns every pair of overlapping prefixes between two address spaces, e.g. of two VNets
+// that are or will be peered
+func FindAddressSpaceOverlaps(addressSpaceA []string, addressSpaceB []string) ([]CIDROverlap, error) {
+	overlaps := []CIDROverlap{}
+
+	for _, prefixA := range addressSpaceA {
+		for _, prefixB := range addressSpaceB {
+			overlap, err := CIDRsOverlap(prefixA, prefixB)
+			if err != nil {
+				return nil, err
+			}
+			if overlap {
+				overlaps = append(overlaps, CIDROverlap{PrefixA: prefixA, PrefixB: prefixB})
+			}
+		}
+	}
+
+	return overlaps, nil
+}
+
+// GetVnetAddressSpace returns the address space prefixes of a Virtual Network returned by GetVnetbyNameE
+func GetVnetAddressSpace(vnet network.VirtualNetwork) []string {
+	if vnet.VirtualNetworkPropertiesFormat == nil || vnet.AddressSpace == nil || vnet.AddressSpace.AddressPrefixes == nil {
+		return []string{}
+	}
+
+	return *vnet.AddressSpace.AddressPrefixes
+}
+
+// GetSubnetAddressPrefixes returns the address prefixes of a Subnet returned by GetSubnetbyNameE. A prefix reported in
+// both AddressPrefix and AddressPrefixes is only returned once.
+func GetSubnetAddressPrefixes(subnet network.Subnet) []string {
+	prefixes := []string{}
+
+	if subnet.SubnetPropertiesFormat == nil {
+		return prefixes
+	}
+
+	seen := map[string]bool{}
+	for _, prefix := range mergeRuleValues(subnet.AddressPrefix, subnet.AddressPrefixes) {
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+		prefixes = append(prefixes, prefix)
+	}
+
+	return prefixes
+}
+
+// GetUsableIPCountOfSubnet returns the number of assignable IPv4 addresses of a Subnet returned by GetSubnetbyNameE.
+// IPv6 prefixes of dual stack subnets are not counted.
+func GetUsableIPCountOfSubnet(subnet network.Subnet) (int64, error) {
+	total := int64(0)
+
+	for _, prefix := range GetSubnetAddressPrefixes(subnet) {
+		ipNet, err := parseCIDR(prefix)
+		if err != nil {
+			return 0, err
+		}
+		if ipNet.IP.To4() == nil {
+			continue
+		}
+
+		usable, err := GetUsableIPCount(prefix)
+		if err != nil {
+			return 0, err
+		}
+		total += usable
+	}
+
+	return total, nil
+}
+
+// CheckVnetAddressPlan returns the problems with the address plan of a Virtual Network returned by GetVnetbyNameE:
+// subnet prefixes that fall outside the VNet address space, and subnets that overlap each other
+func CheckVnetAddressPlan(vnet network.VirtualNetwork) ([]string, error) {
+	problems := []string{}
+
+	addressSpace := GetVnetAddressSpace(vnet)
+
+	// Keep the subnet name with each prefix, as two subnets may be given the same prefix by mistake
+	subnetPrefixes := []subnetAddressPrefix{}
+	if vnet.VirtualNetworkPropertiesFormat != nil && vnet.Subnets != nil {
+		for _, subnet := range *vnet.Subnets {
+			name := ""
+			if subnet.Name != nil {
+				name = *subnet.Name
+			}
+			for _, prefix := range GetSubnetAddressPrefixes(subnet) {
+				subnetPrefixes = append(subnetPrefixes, subnetAddressPrefix{SubnetName: name, Prefix: prefix})
+			}
+		}
+	}
+
+	for _, subnetPrefix := range subnetPrefixes {
+		outside, err := FindPrefixesOutsideAddressSpace(addressSpace, []string{subnetPrefix.Prefix})
+		if err != nil {
+			return nil, err
+		}
+		if len(outside) > 0 {
+			problems = append(problems, fmt.Sprintf("Subnet %s prefix %s is outside the VNet address space %v", subnetPrefix.SubnetName, subnetPrefix.Prefix, addressSpace))
+		}
+	}
+
+	for i := range subnetPrefixes {
+		for j := i + 1; j < len(subnetPrefixes); j++ {
+			a, b := subnetPrefixes[i], subnetPrefixes[j]
+
+			overlap, err := CIDRsOverlap(a.Prefix, b.Prefix)
+			if err != nil {
+				return nil, err
+			}
+			if overlap {
+				problems = append(problems, fmt.Sprintf("Subnet %s prefix %s overlaps subnet %s prefix %s", a.SubnetName, a.Prefix, b.SubnetName, b.Prefix))
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// AssertVnetAddressPlanValid checks that every subnet of the given Virtual Network lies within its address space and
+// that no subnets overlap
+func AssertVnetAddressPlanValid(t *testing.T, vnet network.VirtualNetwork) {
+	problems, err := CheckVnetAddressPlan(vnet)
+	require.NoError(t, err)
+
+	for _, problem := range problems {
+		assert.Fail(t, "VNet address plan is not valid", problem)
+	}
+}
+
+// AssertVnetsDoNotOverlap checks that the address spaces of two Virtual Networks, e.g. a hub and a spoke, do not
+// overlap so that they can be peered
+func AssertVnetsDoNotOverlap(t *testing.T, vnetA network.VirtualNetwork, vnetB network.VirtualNetwork) {
+	overlaps, err := FindAddressSpaceOverlaps(GetVnetAddressSpace(vnetA), GetVnetAddressSpace(vnetB))
+	require.NoError(t, err)
+
+	assert.Empty(t, overlaps, "Check address spaces of the VNets do not overlap")
+}
+
+// parseCIDR parses a prefix, rejecting prefixes with host bits set, e.g. 10.0.0.1/24, as Azure does
+func parseCIDR(prefix string) (*net.IPNet, error) {
+	ip, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, CIDRNotValid{CIDR: prefix, Reason: "not in CIDR notation"}
+	}
+	if !bytes.Equal(ip.To16(), ipNet.IP.To16()) {
+		return nil, CIDRNotValid{CIDR: prefix, Reason: fmt.Sprintf("host bits are set, did you mean %s", ipNet)}
+	}
+
+	return ipNet, nil
+}
@@ -0,0 +1,123 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUsableIPCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		prefix  string
+		want    int64
+		wantErr bool
+	}{
+		{name: "Slash24", prefix: "10.0.0.0/24", want: 251},
+		{name: "Slash29", prefix: "10.0.0.0/29", want: 3},
+		{name: "Slash16", prefix: "10.0.0.0/16", want: 65531},
+		{name: "Slash32", prefix: "10.0.0.4/32", want: 0},
+		{name: "HostBitsSet", prefix: "10.0.0.1/24", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetUsableIPCount(tt.prefix)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFindPrefixesOutsideAddressSpace(t *testing.T) {
+	t.Parallel()
+
+	outside, err := FindPrefixesOutsideAddressSpace([]string{"10.0.0.0/16", "10.2.0.0/24"}, []string{"10.0.1.0/24", "10.2.0.0/24", "10.1.0.0/24", "10.0.0.0/15"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.1.0.0/24", "10.0.0.0/15"}, outside)
+}
+
+func TestFindOverlappingPrefixes(t *testing.T) {
+	t.Parallel()
+
+	overlaps, err := FindOverlappingPrefixes([]string{"10.0.0.0/24", "10.0.1.0/24", "10.0.0.128/25"})
+	require.NoError(t, err)
+	require.Equal(t, []CIDROverlap{{PrefixA: "10.0.0.0/24", PrefixB: "10.0.0.128/25"}}, overlaps)
+
+	overlaps, err = FindAddressSpaceOverlaps([]string{"10.0.0.0/16"}, []string{"10.1.0.0/16", "10.0.255.0/24"})
+	require.NoError(t, err)
+	require.Equal(t, []CIDROverlap{{PrefixA: "10.0.0.0/16", PrefixB: "10.0.255.0/24"}}, overlaps)
+}
+
+func TestCheckVnetAddressPlan(t *testing.T) {
+	t.Parallel()
+
+	webName, dbName, strayName := "web", "db", "stray"
+	webPrefix, dbPrefix, strayPrefix := "10.0.1.0/24", "10.0.1.128/25", "10.1.0.0/24"
+
+	vnet := network.VirtualNetwork{VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+		AddressSpace: &network.AddressSpace{AddressPrefixes: &[]string{"10.0.0.0/16"}},
+		Subnets: &[]network.Subnet{
+			{Name: &webName, SubnetPropertiesFormat: &network.SubnetPropertiesFormat{AddressPrefix: &webPrefix}},
+			{Name: &dbName, SubnetPropertiesFormat: &network.SubnetPropertiesFormat{AddressPrefix: &dbPrefix}},
+			{Name: &strayName, SubnetPropertiesFormat: &network.SubnetPropertiesFormat{AddressPrefix: &strayPrefix}},
+		},
+	}}
+
+	problems, err := CheckVnetAddressPlan(vnet)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Subnet stray prefix 10.1.0.0/24 is outside the VNet address space [10.0.0.0/16]",
+		"Subnet web prefix 10.0.1.0/24 overlaps subnet db prefix 10.0.1.128/25",
+	}, problems)
+
+	usable, err := GetUsableIPCountOfSubnet((*vnet.Subnets)[0])
+	require.NoError(t, err)
+	require.Equal(t, int64(251), usable)
+
+	// Two subnets given the same prefix are both named in the problem
+	aName, bName, duplicatePrefix := "a", "b", "10.0.1.0/24"
+	duplicates := network.VirtualNetwork{VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+		AddressSpace: &network.AddressSpace{AddressPrefixes: &[]string{"10.0.0.0/16"}},
+		Subnets: &[]network.Subnet{
+			{Name: &aName, SubnetPropertiesFormat: &network.SubnetPropertiesFormat{AddressPrefix: &duplicatePrefix}},
+			{Name: &bName, SubnetPropertiesFormat: &network.SubnetPropertiesFormat{AddressPrefix: &duplicatePrefix}},
+		},
+	}}
+
+	problems, err = CheckVnetAddressPlan(duplicates)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Subnet a prefix 10.0.1.0/24 overlaps subnet b prefix 10.0.1.0/24"}, problems)
+
+	// A subnet reporting its prefix in both AddressPrefix and AddressPrefixes does not overlap itself
+	bothName, bothPrefix := "both", "10.0.2.0/24"
+	both := network.VirtualNetwork{VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+		AddressSpace: &network.AddressSpace{AddressPrefixes: &[]string{"10.0.0.0/16"}},
+		Subnets: &[]network.Subnet{
+			{Name: &bothName, SubnetPropertiesFormat: &network.SubnetPropertiesFormat{AddressPrefix: &bothPrefix, AddressPrefixes: &[]string{bothPrefix}}},
+		},
+	}}
+
+	problems, err = CheckVnetAddressPlan(both)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+
+	require.Equal(t, []string{"10.0.2.0/24"}, GetSubnetAddressPrefixes((*both.Subnets)[0]))
+
+	usable, err = GetUsableIPCountOfSubnet((*both.Subnets)[0])
+	require.NoError(t, err)
+	require.Equal(t, int64(251), usable)
+}
@@ -3,6 +3,7 @@ package azure
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 const (
@@ -44,3 +45,68 @@ func getTargetAzureResourceGroupName(resourceGroupName string) (string, error) {
 
 	return resourceGroupName, nil
 }
+
+// azureResourceID holds the parts of a fully qualified Azure resource ID, e.g.
+// /subscriptions/{id}/resourceGroups/{rg}/providers/Microsoft.Network/virtualNetworks/{vnet}/subnets/{subnet}
+type azureResourceID struct {
+	SubscriptionID string
+	ResourceGroup  string
+	Provider       string
+
+	// Path maps each resource type in the ID to its name, e.g. "virtualNetworks" -> "{vnet}" and "subnets" -> "{subnet}"
+	Path map[string]string
+
+	// Name is the name of the resource the ID points to, i.e. its last segment
+	Name string
+
+	// ParentID is the ID of the resource owning a child resource, e.g. the VNet of a subnet or the NIC of an IP
+	// configuration. It is empty for a top-level resource.
+	ParentID string
+}
+
+// parseAzureResourceID is a helper function to split a fully qualified Azure resource ID into its parts
+func parseAzureResourceID(id string) (azureResourceID, error) {
+	resourceID := azureResourceID{Path: make(map[string]string)}
+
+	segments := strings.Split(strings.Trim(id, "/"), "/")
+	if len(segments) < 4 || len(segments)%2 != 0 {
+		return resourceID, ResourceIDNotValid{ID: id}
+	}
+
+	for i := 0; i < len(segments); i += 2 {
+		key := segments[i]
+		value := segments[i+1]
+
+		switch {
+		case strings.EqualFold(key, "subscriptions") && resourceID.SubscriptionID == "":
+			resourceID.SubscriptionID = value
+		case strings.EqualFold(key, "resourceGroups") && resourceID.ResourceGroup == "":
+			resourceID.ResourceGroup = value
+		case strings.EqualFold(key, "providers") && resourceID.Provider == "":
+			resourceID.Provider = value
+		default:
+			resourceID.Path[key] = value
+		}
+	}
+
+	if resourceID.SubscriptionID == "" {
+		return resourceID, ResourceIDNotValid{ID: id}
+	}
+	resourceID.Name = segments[len(segments)-1]
+
+	// A top-level resource ID has 8 segments: subscription, resource group, provider and a single type and name
+	if len(segments) > 8 {
+		resourceID.ParentID = "/" + strings.Join(segments[:len(segments)-2], "/")
+	}
+
+	return resourceID, nil
+}
+
+// getStringValues returns a copy of an optional list of strings returned by the API, or an empty list when it is not set
+func getStringValues(values *[]string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return append([]string{}, *values...)
+}
@@ -70,3 +70,53 @@ func TestGetTargetAzureResourceGroupName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAzureResourceID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		id      string
+		want    azureResourceID
+		wantErr bool
+	}{
+		{
+			name: "subnetID",
+			id:   "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/snet",
+			want: azureResourceID{
+				SubscriptionID: "sub",
+				ResourceGroup:  "rg",
+				Provider:       "Microsoft.Network",
+				Path:           map[string]string{"virtualNetworks": "vnet", "subnets": "snet"},
+				Name:           "snet",
+				ParentID:       "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+			},
+		},
+		{
+			name: "diskID",
+			id:   "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/osdisk",
+			want: azureResourceID{
+				SubscriptionID: "sub",
+				ResourceGroup:  "rg",
+				Provider:       "Microsoft.Compute",
+				Path:           map[string]string{"disks": "osdisk"},
+				Name:           "osdisk",
+			},
+		},
+		{name: "notAnID", id: "vnet", wantErr: true},
+		{name: "oddSegments", id: "/subscriptions/sub/resourceGroups", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAzureResourceID(tt.id)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -2,9 +2,10 @@ package azure
 
 import (
 	"context"
+	"strings"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-06-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
 	"github.com/stretchr/testify/require"
 )
 
@@ -77,7 +78,7 @@ func GetSizeOfVirtualMachineE(t *testing.T, resGroupName string, vmName string,
 	}
 
 	// Get the details of the target virtual machine
-	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceView)
+	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceViewTypesInstanceView)
 	if err != nil {
 		return "", err
 	}
@@ -111,7 +112,7 @@ func GetTagsForVirtualMachineE(t *testing.T, resGroupName string, vmName string,
 	}
 
 	// Get the details of the target virtual machine
-	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceView)
+	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceViewTypesInstanceView)
 	if err != nil {
 		return tags, err
 	}
@@ -180,7 +181,7 @@ func GetTypeOfVirtualMachineDisksE(t *testing.T, resGroupName string, vmName str
 	}
 
 	// Get the details of the target virtual machine
-	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceView)
+	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceViewTypesInstanceView)
 	if err != nil {
 		return nil, err
 	}
@@ -231,3 +232,27 @@ func GetVirtualMachineExtE(t *testing.T, resGroupName string, vmName string, vmE
 	}
 	return vm, nil
 }
+
+// getPowerStateFromStatuses finds the power state (e.g. "running") in a list of instance view statuses
+func getPowerStateFromStatuses(statuses *[]compute.InstanceViewStatus) string {
+	if statuses == nil {
+		return ""
+	}
+
+	for _, status := range *statuses {
+		if status.Code != nil && strings.HasPrefix(*status.Code, "PowerState/") {
+			return statusCodeValue(*status.Code)
+		}
+	}
+
+	return ""
+}
+
+// statusCodeValue returns the part of an instance view status code after the category, e.g. "running" for "PowerState/running"
+func statusCodeValue(code string) string {
+	if i := strings.Index(code, "/"); i >= 0 {
+		return code[i+1:]
+	}
+
+	return code
+}
@@ -0,0 +1,346 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// DedicatedHostGroup describes an Azure dedicated host group
+type DedicatedHostGroup struct {
+	ID                        string
+	Name                      string
+	Location                  string
+	Zones                     []string
+	PlatformFaultDomainCount  int32
+	SupportAutomaticPlacement bool
+	HostIDs                   []string
+}
+
+// DedicatedHost describes an Azure dedicated host
+type DedicatedHost struct {
+	ID                   string
+	Name                 string
+	Sku                  string
+	PlatformFaultDomain  int32
+	AutoReplaceOnFailure bool
+	LicenseType          string
+	ProvisioningState    string
+	VirtualMachineIDs    []string
+
+	// AvailableCapacity maps each VM size to the number of VMs of that size that still fit on the host. It is only
+	// populated when the host is looked up individually.
+	AvailableCapacity map[string]float64
+}
+
+// DedicatedHostAssignment holds the dedicated host group and dedicated host a Virtual Machine runs on
+type DedicatedHostAssignment struct {
+	HostGroupID string
+	HostID      string
+}
+
+// GetDedicatedHostGroupsClient is a helper function that will setup an Azure Dedicated Host Group client on your behalf
+func GetDedicatedHostGroupsClient(subscriptionID string) (*compute.DedicatedHostGroupsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Dedicated Host Group client
+	hostGroupClient := compute.NewDedicatedHostGroupsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	hostGroupClient.Authorizer = *authorizer
+
+	return &hostGroupClient, nil
+}
+
+// GetDedicatedHostsClient is a helper function that will setup an Azure Dedicated Host client on your behalf
+func GetDedicatedHostsClient(subscriptionID string) (*compute.DedicatedHostsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Dedicated Host client
+	hostClient := compute.NewDedicatedHostsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	hostClient.Authorizer = *authorizer
+
+	return &hostClient, nil
+}
+
+// GetDedicatedHostGroup gets the details of a dedicated host group by Name
+func GetDedicatedHostGroup(t *testing.T, resGroupName string, hostGroupName string, subscriptionID string) DedicatedHostGroup {
+	hostGroup, err := GetDedicatedHostGroupE(t, resGroupName, hostGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return hostGroup
+}
+
+// GetDedicatedHostGroupE gets the details of a dedicated host group by Name
+func GetDedicatedHostGroupE(t *testing.T, resGroupName string, hostGroupName string, subscriptionID string) (DedicatedHostGroup, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return DedicatedHostGroup{}, err
+	}
+
+	// Create a Dedicated Host Group client
+	hostGroupClient, err := GetDedicatedHostGroupsClient(subscriptionID)
+	if err != nil {
+		return DedicatedHostGroup{}, err
+	}
+
+	// Get the details of the host group
+	hostGroup, err := hostGroupClient.Get(context.Background(), resGroupName, hostGroupName, "")
+	if err != nil {
+		return DedicatedHostGroup{}, err
+	}
+
+	return newDedicatedHostGroup(hostGroup), nil
+}
+
+// GetDedicatedHost gets the details and available capacity of a dedicated host in the given host group
+func GetDedicatedHost(t *testing.T, resGroupName string, hostGroupName string, hostName string, subscriptionID string) DedicatedHost {
+	host, err := GetDedicatedHostE(t, resGroupName, hostGroupName, hostName, subscriptionID)
+	require.NoError(t, err)
+
+	return host
+}
+
+// GetDedicatedHostE gets the details and available capacity of a dedicated host in the given host group
+func GetDedicatedHostE(t *testing.T, resGroupName string, hostGroupName string, hostName string, subscriptionID string) (DedicatedHost, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return DedicatedHost{}, err
+	}
+
+	// Create a Dedicated Host client
+	hostClient, err := GetDedicatedHostsClient(subscriptionID)
+	if err != nil {
+		return DedicatedHost{}, err
+	}
+
+	// Get the details of the host together with its instance view, which holds the available capacity
+	host, err := hostClient.Get(context.Background(), resGroupName, hostGroupName, hostName, compute.InstanceViewTypesInstanceView)
+	if err != nil {
+		return DedicatedHost{}, err
+	}
+
+	return newDedicatedHost(host), nil
+}
+
+// GetDedicatedHostsOfHostGroup gets every dedicated host in the given host group. Available capacity is not included;
+// use GetDedicatedHost for that.
+func GetDedicatedHostsOfHostGroup(t *testing.T, resGroupName string, hostGroupName string, subscriptionID string) []DedicatedHost {
+	hosts, err := GetDedicatedHostsOfHostGroupE(t, resGroupName, hostGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return hosts
+}
+
+// GetDedicatedHostsOfHostGroupE gets every dedicated host in the given host group. Available capacity is not included;
+// use GetDedicatedHostE for that.
+func GetDedicatedHostsOfHostGroupE(t *testing.T, resGroupName string, hostGroupName string, subscriptionID string) ([]DedicatedHost, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Dedicated Host client
+	hostClient, err := GetDedicatedHostsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := hostClient.ListByHostGroupComplete(context.Background(), resGroupName, hostGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := []DedicatedHost{}
+	for iterator.NotDone() {
+		hosts = append(hosts, newDedicatedHost(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return hosts, nil
+}
+
+// GetDedicatedHostAssignmentOfVirtualMachine gets the dedicated host group and dedicated host the given Virtual Machine
+// runs on, including hosts picked through automatic placement. Both IDs are empty if the VM is not on a dedicated host.
+func GetDedicatedHostAssignmentOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) DedicatedHostAssignment {
+	assignment, err := GetDedicatedHostAssignmentOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return assignment
+}
+
+// GetDedicatedHostAssignmentOfVirtualMachineE gets the dedicated host group and dedicated host the given Virtual Machine
+// runs on, including hosts picked through automatic placement. Both IDs are empty if the VM is not on a dedicated host.
+func GetDedicatedHostAssignmentOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (DedicatedHostAssignment, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return DedicatedHostAssignment{}, err
+	}
+
+	// Create a VM client
+	vmClient, err := GetVirtualMachineClient(subscriptionID)
+	if err != nil {
+		return DedicatedHostAssignment{}, err
+	}
+
+	// Get the VM together with its instance view, which holds the host picked through automatic placement
+	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceViewTypesInstanceView)
+	if err != nil {
+		return DedicatedHostAssignment{}, err
+	}
+
+	return newDedicatedHostAssignment(vm), nil
+}
+
+// AssertVirtualMachineOnDedicatedHostGroup checks that the given Virtual Machine runs on the dedicated host group with
+// the given resource ID
+func AssertVirtualMachineOnDedicatedHostGroup(t *testing.T, resGroupName string, vmName string, hostGroupID string, subscriptionID string) {
+	assignment := GetDedicatedHostAssignmentOfVirtualMachine(t, resGroupName, vmName, subscriptionID)
+
+	assert.True(
+		t,
+		strings.EqualFold(hostGroupID, assignment.HostGroupID),
+		"Check Virtual Machine %s runs on dedicated host group %s, got %q", vmName, hostGroupID, assignment.HostGroupID,
+	)
+}
+
+// newDedicatedHostAssignment works out the dedicated host group and host of a Virtual Machine. A VM placed through
+// automatic placement only references the host group; the host it landed on is reported in its instance view.
+func newDedicatedHostAssignment(vm compute.VirtualMachine) DedicatedHostAssignment {
+	profile := GetSchedulingProfileFromVirtualMachine(vm)
+	assignment := DedicatedHostAssignment{HostGroupID: profile.HostGroupID, HostID: profile.HostID}
+
+	props := vm.VirtualMachineProperties
+	if assignment.HostID == "" && props != nil && props.InstanceView != nil && props.InstanceView.AssignedHost != nil {
+		assignment.HostID = *props.InstanceView.AssignedHost
+	}
+
+	// A VM placed on a specific host only references the host, whose ID is nested under its host group
+	if assignment.HostGroupID == "" && assignment.HostID != "" {
+		if index := strings.LastIndex(strings.ToLower(assignment.HostID), "/hosts/"); index > 0 {
+			assignment.HostGroupID = assignment.HostID[:index]
+		}
+	}
+
+	return assignment
+}
+
+// newDedicatedHostGroup converts a host group returned by the API into a DedicatedHostGroup
+func newDedicatedHostGroup(hostGroup compute.DedicatedHostGroup) DedicatedHostGroup {
+	result := DedicatedHostGroup{Zones: []string{}, HostIDs: []string{}}
+
+	if hostGroup.ID != nil {
+		result.ID = *hostGroup.ID
+	}
+	if hostGroup.Name != nil {
+		result.Name = *hostGroup.Name
+	}
+	if hostGroup.Location != nil {
+		result.Location = *hostGroup.Location
+	}
+	if hostGroup.Zones != nil {
+		result.Zones = *hostGroup.Zones
+	}
+
+	props := hostGroup.DedicatedHostGroupProperties
+	if props == nil {
+		return result
+	}
+
+	if props.PlatformFaultDomainCount != nil {
+		result.PlatformFaultDomainCount = *props.PlatformFaultDomainCount
+	}
+	if props.SupportAutomaticPlacement != nil {
+		result.SupportAutomaticPlacement = *props.SupportAutomaticPlacement
+	}
+	if props.Hosts != nil {
+		for _, host := range *props.Hosts {
+			if host.ID != nil {
+				result.HostIDs = append(result.HostIDs, *host.ID)
+			}
+		}
+	}
+
+	return result
+}
+
+// newDedicatedHost converts a host returned by the API into a DedicatedHost
+func newDedicatedHost(host compute.DedicatedHost) DedicatedHost {
+	result := DedicatedHost{VirtualMachineIDs: []string{}, AvailableCapacity: make(map[string]float64)}
+
+	if host.ID != nil {
+		result.ID = *host.ID
+	}
+	if host.Name != nil {
+		result.Name = *host.Name
+	}
+	if host.Sku != nil && host.Sku.Name != nil {
+		result.Sku = *host.Sku.Name
+	}
+
+	props := host.DedicatedHostProperties
+	if props == nil {
+		return result
+	}
+
+	result.LicenseType = string(props.LicenseType)
+
+	if props.PlatformFaultDomain != nil {
+		result.PlatformFaultDomain = *props.PlatformFaultDomain
+	}
+	if props.AutoReplaceOnFailure != nil {
+		result.AutoReplaceOnFailure = *props.AutoReplaceOnFailure
+	}
+	if props.ProvisioningState != nil {
+		result.ProvisioningState = *props.ProvisioningState
+	}
+	if props.VirtualMachines != nil {
+		for _, vm := range *props.VirtualMachines {
+			if vm.ID != nil {
+				result.VirtualMachineIDs = append(result.VirtualMachineIDs, *vm.ID)
+			}
+		}
+	}
+	if props.InstanceView != nil && props.InstanceView.AvailableCapacity != nil && props.InstanceView.AvailableCapacity.AllocatableVMs != nil {
+		for _, allocatable := range *props.InstanceView.AvailableCapacity.AllocatableVMs {
+			if allocatable.VMSize != nil && allocatable.Count != nil {
+				result.AvailableCapacity[*allocatable.VMSize] = *allocatable.Count
+			}
+		}
+	}
+
+	return result
+}
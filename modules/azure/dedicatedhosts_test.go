@@ -0,0 +1,50 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDedicatedHostAssignment(t *testing.T) {
+	t.Parallel()
+
+	hostGroupID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/hostGroups/hg"
+	hostID := hostGroupID + "/hosts/host1"
+
+	automatic := compute.VirtualMachine{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			HostGroup:    &compute.SubResource{ID: &hostGroupID},
+			InstanceView: &compute.VirtualMachineInstanceView{AssignedHost: &hostID},
+		},
+	}
+	specific := compute.VirtualMachine{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			Host: &compute.SubResource{ID: &hostID},
+		},
+	}
+
+	tests := []struct {
+		name string
+		vm   compute.VirtualMachine
+		want DedicatedHostAssignment
+	}{
+		{name: "AutomaticPlacement", vm: automatic, want: DedicatedHostAssignment{HostGroupID: hostGroupID, HostID: hostID}},
+		{name: "SpecificHost", vm: specific, want: DedicatedHostAssignment{HostGroupID: hostGroupID, HostID: hostID}},
+		{name: "NoDedicatedHost", vm: compute.VirtualMachine{VirtualMachineProperties: &compute.VirtualMachineProperties{}}, want: DedicatedHostAssignment{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newDedicatedHostAssignment(tt.vm)
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+// ManagedDisk holds the size, performance, encryption and attachment details of an Azure Managed Disk
+type ManagedDisk struct {
+	ID       string
+	Name     string
+	SizeGB   int32
+	Sku      string
+	Zones    []string
+	OsType   string
+	IOPS     int64
+	MBps     int64
+	State    string
+	Location string
+
+	// EncryptionType is the server side encryption type, e.g. EncryptionAtRestWithPlatformKey
+	EncryptionType      string
+	DiskEncryptionSetID string
+
+	// NetworkAccessPolicy controls export/import access to the disk, e.g. AllowAll, AllowPrivate or DenyAll
+	NetworkAccessPolicy string
+
+	// ManagedBy is the ID of the Virtual Machine the disk is attached to, or empty if it is unattached
+	ManagedBy string
+
+	// CreateOption is how the disk was created, e.g. FromImage, Empty or Copy
+	CreateOption string
+
+	// CreationSource is the ID or URI of the image, snapshot, disk or blob the disk was created from, if any
+	CreationSource string
+}
+
+// GetDisksClient is a helper function that will setup an Azure Managed Disks client on your behalf
+func GetDisksClient(subscriptionID string) (*compute.DisksClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Disks client
+	diskClient := compute.NewDisksClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	diskClient.Authorizer = *authorizer
+
+	return &diskClient, nil
+}
+
+// GetManagedDisk gets the details of an Azure Managed Disk by Name
+func GetManagedDisk(t *testing.T, resGroupName string, diskName string, subscriptionID string) ManagedDisk {
+	disk, err := GetManagedDiskE(t, resGroupName, diskName, subscriptionID)
+	require.NoError(t, err)
+
+	return disk
+}
+
+// GetManagedDiskE gets the details of an Azure Managed Disk by Name
+func GetManagedDiskE(t *testing.T, resGroupName string, diskName string, subscriptionID string) (ManagedDisk, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return ManagedDisk{}, err
+	}
+
+	// Create a Disks client
+	diskClient, err := GetDisksClient(subscriptionID)
+	if err != nil {
+		return ManagedDisk{}, err
+	}
+
+	// Get the details of the target disk
+	disk, err := diskClient.Get(context.Background(), resGroupName, diskName)
+	if err != nil {
+		return ManagedDisk{}, err
+	}
+
+	return newManagedDisk(disk), nil
+}
+
+// GetManagedDisksOfVirtualMachine gets the details of the OS and Data disks attached to the given Virtual Machine
+func GetManagedDisksOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) []ManagedDisk {
+	disks, err := GetManagedDisksOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return disks
+}
+
+// GetManagedDisksOfVirtualMachineE gets the details of the OS and Data disks attached to the given Virtual Machine
+func GetManagedDisksOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) ([]ManagedDisk, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the IDs of the OS disk and all attached data disks
+	diskIDs := []string{}
+	if vm.VirtualMachineProperties != nil && vm.StorageProfile != nil {
+		osDisk := vm.StorageProfile.OsDisk
+		if osDisk != nil && osDisk.ManagedDisk != nil && osDisk.ManagedDisk.ID != nil {
+			diskIDs = append(diskIDs, *osDisk.ManagedDisk.ID)
+		}
+		if vm.StorageProfile.DataDisks != nil {
+			for _, dataDisk := range *vm.StorageProfile.DataDisks {
+				if dataDisk.ManagedDisk != nil && dataDisk.ManagedDisk.ID != nil {
+					diskIDs = append(diskIDs, *dataDisk.ManagedDisk.ID)
+				}
+			}
+		}
+	}
+
+	// Create a Disks client
+	diskClient, err := GetDisksClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Disks may live in a different resource group from the VM, so look each one up by its ID
+	disks := []ManagedDisk{}
+	for _, diskID := range diskIDs {
+		id, err := parseAzureResourceID(diskID)
+		if err != nil {
+			return nil, err
+		}
+
+		disk, err := diskClient.Get(context.Background(), id.ResourceGroup, id.Name)
+		if err != nil {
+			return nil, err
+		}
+		disks = append(disks, newManagedDisk(disk))
+	}
+
+	return disks, nil
+}
+
+// GetUnattachedManagedDisks gets the Managed Disks in the given resource group that are not attached to any Virtual Machine.
+// This is useful to catch data disks leaked after a destroy.
+func GetUnattachedManagedDisks(t *testing.T, resGroupName string, subscriptionID string) []ManagedDisk {
+	disks, err := GetUnattachedManagedDisksE(t, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return disks
+}
+
+// GetUnattachedManagedDisksE gets the Managed Disks in the given resource group that are not attached to any Virtual Machine.
+// This is useful to catch data disks leaked after a destroy.
+func GetUnattachedManagedDisksE(t *testing.T, resGroupName string, subscriptionID string) ([]ManagedDisk, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Disks client
+	diskClient, err := GetDisksClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := diskClient.ListByResourceGroupComplete(context.Background(), resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	disks := []ManagedDisk{}
+	for iterator.NotDone() {
+		disks = append(disks, newManagedDisk(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return getUnattachedManagedDisks(disks), nil
+}
+
+// getUnattachedManagedDisks returns the disks that are not managed by any Virtual Machine
+func getUnattachedManagedDisks(disks []ManagedDisk) []ManagedDisk {
+	unattached := []ManagedDisk{}
+
+	for _, disk := range disks {
+		if disk.ManagedBy == "" {
+			unattached = append(unattached, disk)
+		}
+	}
+
+	return unattached
+}
+
+// newManagedDisk converts a disk returned by the API into a ManagedDisk
+func newManagedDisk(disk compute.Disk) ManagedDisk {
+	managedDisk := ManagedDisk{Zones: []string{}}
+
+	if disk.ID != nil {
+		managedDisk.ID = *disk.ID
+	}
+	if disk.Name != nil {
+		managedDisk.Name = *disk.Name
+	}
+	if disk.Location != nil {
+		managedDisk.Location = *disk.Location
+	}
+	if disk.ManagedBy != nil {
+		managedDisk.ManagedBy = *disk.ManagedBy
+	}
+	if disk.Sku != nil {
+		managedDisk.Sku = string(disk.Sku.Name)
+	}
+	if disk.Zones != nil {
+		managedDisk.Zones = append(managedDisk.Zones, *disk.Zones...)
+	}
+
+	props := disk.DiskProperties
+	if props == nil {
+		return managedDisk
+	}
+
+	managedDisk.OsType = string(props.OsType)
+	managedDisk.State = string(props.DiskState)
+	managedDisk.NetworkAccessPolicy = string(props.NetworkAccessPolicy)
+
+	if props.DiskSizeGB != nil {
+		managedDisk.SizeGB = *props.DiskSizeGB
+	}
+	if props.DiskIOPSReadWrite != nil {
+		managedDisk.IOPS = *props.DiskIOPSReadWrite
+	}
+	if props.DiskMBpsReadWrite != nil {
+		managedDisk.MBps = *props.DiskMBpsReadWrite
+	}
+
+	if props.Encryption != nil {
+		managedDisk.EncryptionType = string(props.Encryption.Type)
+		if props.Encryption.DiskEncryptionSetID != nil {
+			managedDisk.DiskEncryptionSetID = *props.Encryption.DiskEncryptionSetID
+		}
+	}
+
+	if creation := props.CreationData; creation != nil {
+		managedDisk.CreateOption = string(creation.CreateOption)
+
+		switch {
+		case creation.SourceResourceID != nil:
+			managedDisk.CreationSource = *creation.SourceResourceID
+		case creation.GalleryImageReference != nil && creation.GalleryImageReference.ID != nil:
+			managedDisk.CreationSource = *creation.GalleryImageReference.ID
+		case creation.ImageReference != nil && creation.ImageReference.ID != nil:
+			managedDisk.CreationSource = *creation.ImageReference.ID
+		case creation.SourceURI != nil:
+			managedDisk.CreationSource = *creation.SourceURI
+		}
+	}
+
+	return managedDisk
+}
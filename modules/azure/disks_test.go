@@ -0,0 +1,105 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewManagedDisk(t *testing.T) {
+	t.Parallel()
+
+	id := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/data-disk"
+	name := "data-disk"
+	vmID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"
+	snapshotID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/snapshots/snap"
+	desID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des"
+	sizeGB := int32(128)
+	iops := int64(500)
+	mbps := int64(100)
+
+	disk := newManagedDisk(compute.Disk{
+		ID:        &id,
+		Name:      &name,
+		ManagedBy: &vmID,
+		Sku:       &compute.DiskSku{Name: compute.PremiumLRS},
+		Zones:     &[]string{"1"},
+		DiskProperties: &compute.DiskProperties{
+			DiskSizeGB:        &sizeGB,
+			DiskIOPSReadWrite: &iops,
+			DiskMBpsReadWrite: &mbps,
+			DiskState:         compute.Attached,
+			Encryption:        &compute.Encryption{Type: compute.EncryptionTypeEncryptionAtRestWithCustomerKey, DiskEncryptionSetID: &desID},
+			CreationData:      &compute.CreationData{CreateOption: compute.Copy, SourceResourceID: &snapshotID},
+		},
+	})
+
+	require.Equal(t, ManagedDisk{
+		ID:                  id,
+		Name:                name,
+		SizeGB:              128,
+		Sku:                 "Premium_LRS",
+		Zones:               []string{"1"},
+		IOPS:                500,
+		MBps:                100,
+		State:               "Attached",
+		EncryptionType:      "EncryptionAtRestWithCustomerKey",
+		DiskEncryptionSetID: desID,
+		ManagedBy:           vmID,
+		CreateOption:        "Copy",
+		CreationSource:      snapshotID,
+	}, disk)
+}
+
+func TestGetUnattachedManagedDisks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		disks []ManagedDisk
+		want  []ManagedDisk
+	}{
+		{name: "noDisks", disks: []ManagedDisk{}, want: []ManagedDisk{}},
+		{name: "allAttached", disks: []ManagedDisk{{Name: "os", ManagedBy: "vm"}}, want: []ManagedDisk{}},
+		{
+			name:  "leakedDataDisk",
+			disks: []ManagedDisk{{Name: "os", ManagedBy: "vm"}, {Name: "data"}},
+			want:  []ManagedDisk{{Name: "data"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, getUnattachedManagedDisks(tt.disks))
+		})
+	}
+}
+
+func TestGetManagedDiskE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	diskName := ""
+	subID := ""
+
+	_, err := GetManagedDiskE(t, rgName, diskName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetUnattachedManagedDisksE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	subID := ""
+
+	_, err := GetUnattachedManagedDisksE(t, rgName, subID)
+
+	require.Error(t, err)
+}
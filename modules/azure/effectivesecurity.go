@@ -0,0 +1,233 @@
+package azure
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// EffectiveSecurityVerdict is the outcome of evaluating a flow against both the subnet and the NIC Network Security
+// Group of a Virtual Machine
+type EffectiveSecurityVerdict struct {
+	Allowed bool
+
+	// Decision is the verdict of the NSG that decided the outcome: the first NSG to deny the flow, or otherwise the last
+	// NSG to allow it. It is empty when neither the subnet nor the NIC has an NSG, in which case the flow is allowed.
+	Decision SecurityRuleVerdict
+
+	// Evaluations holds the verdict of each NSG in the order they were evaluated
+	Evaluations []SecurityRuleVerdict
+}
+
+// String describes the verdict, e.g. "Deny by rule DenyAllInBound (priority 65500) of NSG web-nsg"
+func (verdict EffectiveSecurityVerdict) String() string {
+	if len(verdict.Evaluations) == 0 {
+		return "Allow as neither the subnet nor the NIC has an NSG"
+	}
+
+	return verdict.Decision.String()
+}
+
+// EvaluateEffectiveSecurity evaluates the given flow against the subnet and NIC Network Security Groups, either of
+// which may be nil. Inbound traffic passes the subnet NSG first and then the NIC NSG, while outbound traffic passes the
+// NIC NSG first and then the subnet NSG. The flow is only allowed if every NSG on the way allows it.
+func EvaluateEffectiveSecurity(subnetNSG *NetworkSecurityGroup, nicNSG *NetworkSecurityGroup, flow NetworkFlow) (EffectiveSecurityVerdict, error) {
+	result := EffectiveSecurityVerdict{Allowed: true, Evaluations: []SecurityRuleVerdict{}}
+
+	if err := validateNetworkFlow(flow); err != nil {
+		return result, err
+	}
+
+	nsgs := []*NetworkSecurityGroup{subnetNSG, nicNSG}
+	if strings.EqualFold(flow.Direction, string(network.SecurityRuleDirectionOutbound)) {
+		nsgs = []*NetworkSecurityGroup{nicNSG, subnetNSG}
+	}
+
+	for _, nsg := range nsgs {
+		if nsg == nil {
+			continue
+		}
+
+		verdict, err := EvaluateNetworkSecurityGroup(*nsg, flow)
+		if err != nil {
+			return result, err
+		}
+
+		result.Evaluations = append(result.Evaluations, verdict)
+		result.Decision = verdict
+
+		if !verdict.Allowed {
+			result.Allowed = false
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// EvaluateEffectiveSecurityOfVirtualMachine evaluates the given flow against the subnet and NIC Network Security Groups
+// of the given Virtual Machine. The NIC is picked by matching the VM side of the flow (the destination address for
+// inbound flows, the source address for outbound flows) against its private IPs. If the VM side address is not an IP
+// address, e.g. it is empty, the primary NIC is used and the address is filled in with its private IP. When no
+// Application Security Groups are given for the VM side, those of the NIC are used. The E variant returns
+// VMNetworkInterfaceAddressNotFound if the VM side IP address belongs to none of the NICs.
+func EvaluateEffectiveSecurityOfVirtualMachine(t *testing.T, resGroupName string, vmName string, flow NetworkFlow, subscriptionID string) EffectiveSecurityVerdict {
+	verdict, err := EvaluateEffectiveSecurityOfVirtualMachineE(t, resGroupName, vmName, flow, subscriptionID)
+	require.NoError(t, err)
+
+	return verdict
+}
+
+// EvaluateEffectiveSecurityOfVirtualMachineE evaluates the given flow against the subnet and NIC Network Security Groups
+// of the given Virtual Machine. See EvaluateEffectiveSecurityOfVirtualMachine for how the NIC is picked.
+func EvaluateEffectiveSecurityOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, flow NetworkFlow, subscriptionID string) (EffectiveSecurityVerdict, error) {
+	nics, err := GetNetworkInterfacesOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return EffectiveSecurityVerdict{}, err
+	}
+
+	nic, ipConfig, found := selectNetworkInterfaceForFlow(nics, flow)
+	if !found {
+		if vmAddress := getVirtualMachineSideAddress(flow); net.ParseIP(vmAddress) != nil {
+			return EffectiveSecurityVerdict{}, VMNetworkInterfaceAddressNotFound{VMName: vmName, Address: vmAddress}
+		}
+		return EffectiveSecurityVerdict{}, VMNetworkInterfaceNotFound{VMName: vmName}
+	}
+	flow = completeFlowForNetworkInterface(flow, ipConfig)
+
+	var nicNSG *NetworkSecurityGroup
+	if nic.NetworkSecurityGroupID != "" {
+		nsg, err := getNetworkSecurityGroupByIDE(t, nic.NetworkSecurityGroupID)
+		if err != nil {
+			return EffectiveSecurityVerdict{}, err
+		}
+		nicNSG = &nsg
+	}
+
+	var subnetNSG *NetworkSecurityGroup
+	if ipConfig.SubnetID != "" {
+		subnetID, err := parseAzureResourceID(ipConfig.SubnetID)
+		if err != nil {
+			return EffectiveSecurityVerdict{}, err
+		}
+
+		subnet, err := GetSubnetbyNameE(t, subnetID.ResourceGroup, subnetID.Path["virtualNetworks"], subnetID.Name, subnetID.SubscriptionID)
+		if err != nil {
+			return EffectiveSecurityVerdict{}, err
+		}
+
+		if subnet.SubnetPropertiesFormat != nil && subnet.NetworkSecurityGroup != nil && subnet.NetworkSecurityGroup.ID != nil {
+			nsg, err := getNetworkSecurityGroupByIDE(t, *subnet.NetworkSecurityGroup.ID)
+			if err != nil {
+				return EffectiveSecurityVerdict{}, err
+			}
+			subnetNSG = &nsg
+		}
+	}
+
+	return EvaluateEffectiveSecurity(subnetNSG, nicNSG, flow)
+}
+
+// AssertVirtualMachinePortOpen checks that the given flow is allowed by both the subnet and NIC Network Security Groups
+// of the given Virtual Machine
+func AssertVirtualMachinePortOpen(t *testing.T, resGroupName string, vmName string, flow NetworkFlow, subscriptionID string) {
+	verdict := EvaluateEffectiveSecurityOfVirtualMachine(t, resGroupName, vmName, flow, subscriptionID)
+
+	assert.True(t, verdict.Allowed, "Expected %s to be allowed to Virtual Machine %s, got %s", flow, vmName, verdict)
+}
+
+// AssertVirtualMachinePortClosed checks that the given flow is denied by the subnet or NIC Network Security Group of
+// the given Virtual Machine
+func AssertVirtualMachinePortClosed(t *testing.T, resGroupName string, vmName string, flow NetworkFlow, subscriptionID string) {
+	verdict := EvaluateEffectiveSecurityOfVirtualMachine(t, resGroupName, vmName, flow, subscriptionID)
+
+	assert.False(t, verdict.Allowed, "Expected %s to be denied to Virtual Machine %s, got %s", flow, vmName, verdict)
+}
+
+// getNetworkSecurityGroupByIDE gets a Network Security Group by its resource ID, which may be in another resource group
+// or subscription
+func getNetworkSecurityGroupByIDE(t *testing.T, nsgID string) (NetworkSecurityGroup, error) {
+	id, err := parseAzureResourceID(nsgID)
+	if err != nil {
+		return NetworkSecurityGroup{}, err
+	}
+
+	return GetNetworkSecurityGroupE(t, id.ResourceGroup, id.Name, id.SubscriptionID)
+}
+
+// selectNetworkInterfaceForFlow picks the NIC and IP configuration that owns the VM side IP address of the flow. If the
+// VM side address is not an IP address, it falls back to the primary IP configuration of the primary NIC.
+func selectNetworkInterfaceForFlow(nics []NetworkInterface, flow NetworkFlow) (NetworkInterface, NetworkInterfaceIPConfiguration, bool) {
+	vmAddress := getVirtualMachineSideAddress(flow)
+
+	if vmIP := net.ParseIP(vmAddress); vmIP != nil {
+		for _, nic := range nics {
+			for _, ipConfig := range nic.IPConfigurations {
+				if vmIP.Equal(net.ParseIP(ipConfig.PrivateIPAddress)) {
+					return nic, ipConfig, true
+				}
+			}
+		}
+
+		// Evaluating another NIC would check a flow to an address the VM does not have
+		return NetworkInterface{}, NetworkInterfaceIPConfiguration{}, false
+	}
+
+	var fallback *NetworkInterface
+	for i := range nics {
+		if len(nics[i].IPConfigurations) == 0 {
+			continue
+		}
+		if fallback == nil || (nics[i].Primary && !fallback.Primary) {
+			fallback = &nics[i]
+		}
+	}
+	if fallback == nil {
+		return NetworkInterface{}, NetworkInterfaceIPConfiguration{}, false
+	}
+
+	for _, ipConfig := range fallback.IPConfigurations {
+		if ipConfig.Primary {
+			return *fallback, ipConfig, true
+		}
+	}
+
+	return *fallback, fallback.IPConfigurations[0], true
+}
+
+// completeFlowForNetworkInterface fills in the VM side address and Application Security Groups of the flow from the
+// given IP configuration where they are not set
+func completeFlowForNetworkInterface(flow NetworkFlow, ipConfig NetworkInterfaceIPConfiguration) NetworkFlow {
+	if strings.EqualFold(flow.Direction, string(network.SecurityRuleDirectionOutbound)) {
+		if flow.SourceAddress == "" {
+			flow.SourceAddress = ipConfig.PrivateIPAddress
+		}
+		if len(flow.SourceApplicationSecurityGroupIDs) == 0 {
+			flow.SourceApplicationSecurityGroupIDs = ipConfig.ApplicationSecurityGroupIDs
+		}
+		return flow
+	}
+
+	if flow.DestinationAddress == "" {
+		flow.DestinationAddress = ipConfig.PrivateIPAddress
+	}
+	if len(flow.DestinationApplicationSecurityGroupIDs) == 0 {
+		flow.DestinationApplicationSecurityGroupIDs = ipConfig.ApplicationSecurityGroupIDs
+	}
+
+	return flow
+}
+
+// getVirtualMachineSideAddress returns the address of the flow that belongs to the VM: the destination for inbound
+// flows and the source for outbound flows
+func getVirtualMachineSideAddress(flow NetworkFlow) string {
+	if strings.EqualFold(flow.Direction, string(network.SecurityRuleDirectionOutbound)) {
+		return flow.SourceAddress
+	}
+
+	return flow.DestinationAddress
+}
@@ -0,0 +1,109 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateEffectiveSecurity(t *testing.T) {
+	t.Parallel()
+
+	allowHTTPS := SecurityRule{
+		Name: "AllowHTTPS", Priority: 100, Direction: "Inbound", Access: "Allow", Protocol: "Tcp",
+		SourceAddressPrefixes: []string{"*"}, SourcePortRanges: []string{"*"},
+		DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"443"},
+	}
+	denyOutboundHTTPS := SecurityRule{
+		Name: "DenyHTTPSOut", Priority: 100, Direction: "Outbound", Access: "Deny", Protocol: "Tcp",
+		SourceAddressPrefixes: []string{"*"}, SourcePortRanges: []string{"*"},
+		DestinationAddressPrefixes: []string{"Internet"}, DestinationPortRanges: []string{"443"},
+	}
+
+	allowSSHFromAnywhere := SecurityRule{
+		Name: "AllowSSHFromAnywhere", Priority: 200, Direction: "Inbound", Access: "Allow", Protocol: "Tcp",
+		SourceAddressPrefixes: []string{"0.0.0.0/0"}, SourcePortRanges: []string{"*"},
+		DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"22"},
+	}
+
+	subnetNSG := NetworkSecurityGroup{Name: "subnet-nsg", SecurityRules: []SecurityRule{allowHTTPS, allowSSHFromAnywhere}}
+	nicNSG := NetworkSecurityGroup{Name: "nic-nsg", SecurityRules: []SecurityRule{denyOutboundHTTPS}}
+	sshNICNSG := NetworkSecurityGroup{Name: "ssh-nic-nsg", SecurityRules: []SecurityRule{allowSSHFromAnywhere}}
+
+	inbound := NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 443}
+	outbound := NetworkFlow{Direction: "Outbound", Protocol: "Tcp", SourceAddress: "10.0.1.4", DestinationAddress: "Internet", DestinationPort: 443}
+	inboundSSH := NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 22}
+
+	tests := []struct {
+		name                string
+		subnetNSG           *NetworkSecurityGroup
+		nicNSG              *NetworkSecurityGroup
+		flow                NetworkFlow
+		wantAllowed         bool
+		wantDecidingNSG     string
+		wantDecidingRule    string
+		wantEvaluationCount int
+	}{
+		{name: "InboundAllowedBySubnetDeniedByNIC", subnetNSG: &subnetNSG, nicNSG: &nicNSG, flow: inbound, wantAllowed: false, wantDecidingNSG: "nic-nsg", wantDecidingRule: "DenyAllInBound", wantEvaluationCount: 2},
+		{name: "InboundOnlySubnetNSG", subnetNSG: &subnetNSG, nicNSG: nil, flow: inbound, wantAllowed: true, wantDecidingNSG: "subnet-nsg", wantDecidingRule: "AllowHTTPS", wantEvaluationCount: 1},
+		{name: "InboundTagSourceAllowedBySubnetAndNIC", subnetNSG: &subnetNSG, nicNSG: &sshNICNSG, flow: inboundSSH, wantAllowed: true, wantDecidingNSG: "ssh-nic-nsg", wantDecidingRule: "AllowSSHFromAnywhere", wantEvaluationCount: 2},
+		{name: "InboundTagSourceAllowedBySubnetDeniedByNIC", subnetNSG: &subnetNSG, nicNSG: &nicNSG, flow: inboundSSH, wantAllowed: false, wantDecidingNSG: "nic-nsg", wantDecidingRule: "DenyAllInBound", wantEvaluationCount: 2},
+		{name: "OutboundDeniedByNICFirst", subnetNSG: &subnetNSG, nicNSG: &nicNSG, flow: outbound, wantAllowed: false, wantDecidingNSG: "nic-nsg", wantDecidingRule: "DenyHTTPSOut", wantEvaluationCount: 1},
+		{name: "OutboundOnlySubnetNSG", subnetNSG: &subnetNSG, nicNSG: nil, flow: outbound, wantAllowed: true, wantDecidingNSG: "subnet-nsg", wantDecidingRule: "AllowInternetOutBound", wantEvaluationCount: 1},
+		{name: "NoNSGs", subnetNSG: nil, nicNSG: nil, flow: inbound, wantAllowed: true, wantDecidingNSG: "", wantDecidingRule: "", wantEvaluationCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := EvaluateEffectiveSecurity(tt.subnetNSG, tt.nicNSG, tt.flow)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAllowed, verdict.Allowed)
+			require.Equal(t, tt.wantDecidingNSG, verdict.Decision.NetworkSecurityGroupName)
+			require.Equal(t, tt.wantDecidingRule, verdict.Decision.Rule.Name)
+			require.Len(t, verdict.Evaluations, tt.wantEvaluationCount)
+		})
+	}
+}
+
+func TestSelectNetworkInterfaceForFlow(t *testing.T) {
+	t.Parallel()
+
+	nics := []NetworkInterface{
+		{Name: "secondary", IPConfigurations: []NetworkInterfaceIPConfiguration{{Name: "ipconfig1", PrivateIPAddress: "10.0.2.4"}}},
+		{Name: "primary", Primary: true, IPConfigurations: []NetworkInterfaceIPConfiguration{
+			{Name: "ipconfig1", PrivateIPAddress: "10.0.1.5"},
+			{Name: "ipconfig2", Primary: true, PrivateIPAddress: "10.0.1.4", ApplicationSecurityGroupIDs: []string{"web-asg"}},
+		}},
+	}
+
+	nic, ipConfig, found := selectNetworkInterfaceForFlow(nics, NetworkFlow{Direction: "Inbound", DestinationAddress: "10.0.2.4"})
+	require.True(t, found)
+	require.Equal(t, "secondary", nic.Name)
+	require.Equal(t, "ipconfig1", ipConfig.Name)
+
+	nic, ipConfig, found = selectNetworkInterfaceForFlow(nics, NetworkFlow{Direction: "Inbound"})
+	require.True(t, found)
+	require.Equal(t, "primary", nic.Name)
+	require.Equal(t, "ipconfig2", ipConfig.Name)
+
+	flow := completeFlowForNetworkInterface(NetworkFlow{Direction: "Inbound", SourceAddress: "Internet"}, ipConfig)
+	require.Equal(t, "10.0.1.4", flow.DestinationAddress)
+	require.Equal(t, []string{"web-asg"}, flow.DestinationApplicationSecurityGroupIDs)
+
+	_, _, found = selectNetworkInterfaceForFlow([]NetworkInterface{}, NetworkFlow{Direction: "Inbound"})
+	require.False(t, found)
+
+	// An address the VM does not have must not fall back to the primary NIC
+	_, _, found = selectNetworkInterfaceForFlow(nics, NetworkFlow{Direction: "Inbound", DestinationAddress: "10.0.9.9"})
+	require.False(t, found)
+
+	nic, _, found = selectNetworkInterfaceForFlow(nics, NetworkFlow{Direction: "Outbound", SourceAddress: "10.0.1.5"})
+	require.True(t, found)
+	require.Equal(t, "primary", nic.Name)
+}
@@ -1,6 +1,9 @@
 package azure
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // SubscriptionIDNotFound is an error that occurs when the Azure Subscription ID could not be found or was not provided
 type SubscriptionIDNotFound struct{}
@@ -15,3 +18,217 @@ type ResourceGroupNameNotFound struct{}
 func (err ResourceGroupNameNotFound) Error() string {
 	return fmt.Sprintf("Could not find an Azure Resource Group name in expected environment variable %s and one was not provided for this test.", AzureResGroupName)
 }
+
+// VMAgentNotReady is an error that occurs when the guest agent of a Virtual Machine is not ready to run commands
+type VMAgentNotReady struct {
+	VMName string
+	Status string
+}
+
+func (err VMAgentNotReady) Error() string {
+	return fmt.Sprintf("The VM agent on Virtual Machine %s is not ready (status: %s), so commands cannot be run on it.", err.VMName, err.Status)
+}
+
+// RunCommandTimedOut is an error that occurs when a Run Command does not finish within the given timeout
+type RunCommandTimedOut struct {
+	VMName    string
+	CommandID string
+	Timeout   time.Duration
+}
+
+func (err RunCommandTimedOut) Error() string {
+	return fmt.Sprintf("Run Command %s on Virtual Machine %s did not finish within %s.", err.CommandID, err.VMName, err.Timeout)
+}
+
+// ResourceIDNotValid is an error that occurs when a string cannot be parsed as a fully qualified Azure resource ID
+type ResourceIDNotValid struct {
+	ID string
+}
+
+func (err ResourceIDNotValid) Error() string {
+	return fmt.Sprintf("%q is not a valid Azure resource ID.", err.ID)
+}
+
+// MarketplaceImageNotFound is an error that occurs when a Virtual Machine was not built from a marketplace image with a resolved version
+type MarketplaceImageNotFound struct {
+	VMName string
+}
+
+func (err MarketplaceImageNotFound) Error() string {
+	return fmt.Sprintf("Virtual Machine %s was not built from a marketplace image with a known version.", err.VMName)
+}
+
+// VMLocationNotFound is an error that occurs when the API does not report the region of a Virtual Machine
+type VMLocationNotFound struct {
+	VMName string
+}
+
+func (err VMLocationNotFound) Error() string {
+	return fmt.Sprintf("Location of Virtual Machine %s was not found.", err.VMName)
+}
+
+// BootDiagnosticsNotAvailable is an error that occurs when a Virtual Machine has no serial console log, usually because
+// boot diagnostics are not enabled on it
+type BootDiagnosticsNotAvailable struct {
+	VMName string
+}
+
+func (err BootDiagnosticsNotAvailable) Error() string {
+	return fmt.Sprintf("No serial console log is available for Virtual Machine %s. Check that boot diagnostics are enabled.", err.VMName)
+}
+
+// BootDiagnosticsDownloadFailed is an error that occurs when the serial console log of a Virtual Machine could not be downloaded
+type BootDiagnosticsDownloadFailed struct {
+	VMName     string
+	StatusCode int
+}
+
+func (err BootDiagnosticsDownloadFailed) Error() string {
+	return fmt.Sprintf("Downloading the serial console log of Virtual Machine %s failed with HTTP status %d.", err.VMName, err.StatusCode)
+}
+
+// ExtensionInstanceViewNotFound is an error that occurs when a Virtual Machine extension does not report an instance view
+type ExtensionInstanceViewNotFound struct {
+	VMName        string
+	ExtensionName string
+}
+
+func (err ExtensionInstanceViewNotFound) Error() string {
+	return fmt.Sprintf("Extension %s on Virtual Machine %s did not report an instance view.", err.ExtensionName, err.VMName)
+}
+
+// ComputeUsageNotFound is an error that occurs when the compute usage of a VM family is not reported for a region
+type ComputeUsageNotFound struct {
+	Region string
+	Family string
+}
+
+func (err ComputeUsageNotFound) Error() string {
+	return fmt.Sprintf("No compute usage found for %s in region %s.", err.Family, err.Region)
+}
+
+// QuotaHeadroomInsufficient is an error that occurs when a region does not have enough quota left for the requested cores
+type QuotaHeadroomInsufficient struct {
+	Region    string
+	Family    string
+	Current   int64
+	Limit     int64
+	Requested int64
+}
+
+func (err QuotaHeadroomInsufficient) Error() string {
+	return fmt.Sprintf(
+		"Not enough %s quota in region %s: %d of %d used, %d available but %d requested.",
+		err.Family,
+		err.Region,
+		err.Current,
+		err.Limit,
+		err.Limit-err.Current,
+		err.Requested,
+	)
+}
+
+// NetworkFlowNotValid is an error that occurs when a network flow cannot be evaluated against security rules
+type NetworkFlowNotValid struct {
+	Reason string
+}
+
+func (err NetworkFlowNotValid) Error() string {
+	return fmt.Sprintf("Network flow is not valid: %s.", err.Reason)
+}
+
+// VMNetworkInterfaceNotFound is an error that occurs when a Virtual Machine has no NIC with an IP configuration
+type VMNetworkInterfaceNotFound struct {
+	VMName string
+}
+
+func (err VMNetworkInterfaceNotFound) Error() string {
+	return fmt.Sprintf("Virtual Machine %s has no network interface with an IP configuration.", err.VMName)
+}
+
+// VMNetworkInterfaceAddressNotFound is an error that occurs when no NIC of a Virtual Machine has the given private IP
+type VMNetworkInterfaceAddressNotFound struct {
+	VMName  string
+	Address string
+}
+
+func (err VMNetworkInterfaceAddressNotFound) Error() string {
+	return fmt.Sprintf("Virtual Machine %s has no network interface with private IP %s.", err.VMName, err.Address)
+}
+
+// NetworkWatcherNotFound is an error that occurs when no Network Watcher is enabled in a region
+type NetworkWatcherNotFound struct {
+	Region string
+}
+
+func (err NetworkWatcherNotFound) Error() string {
+	return fmt.Sprintf("No Network Watcher found in region %s. Check that Network Watcher is enabled for the region.", err.Region)
+}
+
+// NetworkOperationTimedOut is an error that occurs when a long-running network operation does not finish in time
+type NetworkOperationTimedOut struct {
+	Operation string
+	Timeout   time.Duration
+}
+
+func (err NetworkOperationTimedOut) Error() string {
+	return fmt.Sprintf("%s did not finish within %s.", err.Operation, err.Timeout)
+}
+
+// LoadBalancerBackendPoolNotFound is an error that occurs when a Load Balancer has no backend pool with the given name
+type LoadBalancerBackendPoolNotFound struct {
+	LoadBalancerName string
+	PoolName         string
+}
+
+func (err LoadBalancerBackendPoolNotFound) Error() string {
+	return fmt.Sprintf("Load Balancer %s has no backend pool named %s.", err.LoadBalancerName, err.PoolName)
+}
+
+// ApplicationGatewayFirewallPolicyNotFound is an error that occurs when an Application Gateway has no associated WAF policy
+type ApplicationGatewayFirewallPolicyNotFound struct {
+	AppGatewayName string
+}
+
+func (err ApplicationGatewayFirewallPolicyNotFound) Error() string {
+	return fmt.Sprintf("Application Gateway %s has no associated WAF policy.", err.AppGatewayName)
+}
+
+// IPAddressNotValid is an error that occurs when a string is not a valid IPv4 address
+type IPAddressNotValid struct {
+	Address string
+}
+
+func (err IPAddressNotValid) Error() string {
+	return fmt.Sprintf("%q is not a valid IPv4 address.", err.Address)
+}
+
+// VnetPeeringNotFound is an error that occurs when a Virtual Network has no peering to the given remote Virtual Network
+type VnetPeeringNotFound struct {
+	VnetID       string
+	RemoteVnetID string
+}
+
+func (err VnetPeeringNotFound) Error() string {
+	return fmt.Sprintf("Virtual Network %s has no peering to %s.", err.VnetID, err.RemoteVnetID)
+}
+
+// CIDRNotValid is an error that occurs when an address prefix cannot be used as an Azure address space or subnet
+type CIDRNotValid struct {
+	CIDR   string
+	Reason string
+}
+
+func (err CIDRNotValid) Error() string {
+	return fmt.Sprintf("%q is not a valid address prefix: %s.", err.CIDR, err.Reason)
+}
+
+// SubnetNotFound is an error that occurs when a Virtual Network has no subnet with the given name
+type SubnetNotFound struct {
+	VnetName   string
+	SubnetName string
+}
+
+func (err SubnetNotFound) Error() string {
+	return fmt.Sprintf("Virtual Network %s has no subnet named %s.", err.VnetName, err.SubnetName)
+}
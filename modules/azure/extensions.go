@@ -0,0 +1,155 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+// VMExtension describes an extension installed on a Virtual Machine
+type VMExtension struct {
+	Name                    string
+	Publisher               string
+	Type                    string
+	TypeHandlerVersion      string
+	AutoUpgradeMinorVersion bool
+	EnableAutomaticUpgrade  bool
+	ProvisioningState       string
+}
+
+// GetVirtualMachineExtensions gets every extension installed on the given Virtual Machine
+func GetVirtualMachineExtensions(t *testing.T, resGroupName string, vmName string, subscriptionID string) []VMExtension {
+	extensions, err := GetVirtualMachineExtensionsE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return extensions
+}
+
+// GetVirtualMachineExtensionsE gets every extension installed on the given Virtual Machine
+func GetVirtualMachineExtensionsE(t *testing.T, resGroupName string, vmName string, subscriptionID string) ([]VMExtension, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VM Extension client
+	vmExtClient, err := GetVirtualMachineExtensionsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// List the extensions of the target virtual machine
+	result, err := vmExtClient.List(context.Background(), resGroupName, vmName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := []VMExtension{}
+	if result.Value == nil {
+		return extensions, nil
+	}
+
+	for _, ext := range *result.Value {
+		extensions = append(extensions, newVMExtension(ext))
+	}
+
+	return extensions, nil
+}
+
+// GetVirtualMachineExtensionOutput gets the StdOut and StdErr reported by a CustomScript or RunCommand extension on the
+// given Virtual Machine, e.g. to check what a bootstrap script printed
+func GetVirtualMachineExtensionOutput(t *testing.T, resGroupName string, vmName string, vmExtName string, subscriptionID string) VMRunCommandResult {
+	output, err := GetVirtualMachineExtensionOutputE(t, resGroupName, vmName, vmExtName, subscriptionID)
+	require.NoError(t, err)
+
+	return output
+}
+
+// GetVirtualMachineExtensionOutputE gets the StdOut and StdErr reported by a CustomScript or RunCommand extension on the
+// given Virtual Machine, e.g. to check what a bootstrap script printed
+func GetVirtualMachineExtensionOutputE(t *testing.T, resGroupName string, vmName string, vmExtName string, subscriptionID string) (VMRunCommandResult, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	// Create a VM Extension client
+	vmExtClient, err := GetVirtualMachineExtensionsClient(subscriptionID)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	// Get the extension together with its instance view, which holds the script output
+	ext, err := vmExtClient.Get(context.Background(), resGroupName, vmName, vmExtName, "instanceView")
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	if ext.VirtualMachineExtensionProperties == nil || ext.InstanceView == nil {
+		return VMRunCommandResult{}, ExtensionInstanceViewNotFound{VMName: vmName, ExtensionName: vmExtName}
+	}
+
+	return parseExtensionInstanceView(*ext.InstanceView), nil
+}
+
+// parseExtensionInstanceView converts the instance view of a script extension into a VMRunCommandResult. Windows
+// extensions report the script output as StdOut/StdErr substatuses, while Linux extensions put it in the status message.
+func parseExtensionInstanceView(instanceView compute.VirtualMachineExtensionInstanceView) VMRunCommandResult {
+	statuses := []compute.InstanceViewStatus{}
+	if instanceView.Statuses != nil {
+		statuses = *instanceView.Statuses
+	}
+
+	if instanceView.Substatuses == nil || len(*instanceView.Substatuses) == 0 {
+		return parseScriptStatuses(statuses)
+	}
+
+	result := parseScriptStatuses(*instanceView.Substatuses)
+	for _, status := range statuses {
+		if status.Code != nil && strings.HasSuffix(*status.Code, "/failed") {
+			result.Succeeded = false
+		}
+	}
+
+	return result
+}
+
+// newVMExtension converts an extension returned by the API into a VMExtension
+func newVMExtension(ext compute.VirtualMachineExtension) VMExtension {
+	extension := VMExtension{}
+
+	if ext.Name != nil {
+		extension.Name = *ext.Name
+	}
+
+	props := ext.VirtualMachineExtensionProperties
+	if props == nil {
+		return extension
+	}
+
+	if props.Publisher != nil {
+		extension.Publisher = *props.Publisher
+	}
+	if props.Type != nil {
+		extension.Type = *props.Type
+	}
+	if props.TypeHandlerVersion != nil {
+		extension.TypeHandlerVersion = *props.TypeHandlerVersion
+	}
+	if props.AutoUpgradeMinorVersion != nil {
+		extension.AutoUpgradeMinorVersion = *props.AutoUpgradeMinorVersion
+	}
+	if props.EnableAutomaticUpgrade != nil {
+		extension.EnableAutomaticUpgrade = *props.EnableAutomaticUpgrade
+	}
+	if props.ProvisioningState != nil {
+		extension.ProvisioningState = *props.ProvisioningState
+	}
+
+	return extension
+}
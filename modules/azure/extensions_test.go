@@ -0,0 +1,93 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVMExtension(t *testing.T) {
+	t.Parallel()
+
+	name := "OmsAgentForLinux"
+	publisher := "Microsoft.EnterpriseCloud.Monitoring"
+	extType := "OmsAgentForLinux"
+	version := "1.14"
+	enabled := true
+	state := "Succeeded"
+
+	extension := newVMExtension(compute.VirtualMachineExtension{
+		Name: &name,
+		VirtualMachineExtensionProperties: &compute.VirtualMachineExtensionProperties{
+			Publisher:               &publisher,
+			Type:                    &extType,
+			TypeHandlerVersion:      &version,
+			AutoUpgradeMinorVersion: &enabled,
+			ProvisioningState:       &state,
+		},
+	})
+
+	require.Equal(t, VMExtension{
+		Name:                    "OmsAgentForLinux",
+		Publisher:               "Microsoft.EnterpriseCloud.Monitoring",
+		Type:                    "OmsAgentForLinux",
+		TypeHandlerVersion:      "1.14",
+		AutoUpgradeMinorVersion: true,
+		ProvisioningState:       "Succeeded",
+	}, extension)
+
+	require.Equal(t, VMExtension{Name: "OmsAgentForLinux"}, newVMExtension(compute.VirtualMachineExtension{Name: &name}))
+}
+
+func TestParseExtensionInstanceView(t *testing.T) {
+	t.Parallel()
+
+	status := func(code string, message string) compute.InstanceViewStatus {
+		return compute.InstanceViewStatus{Code: &code, Message: &message}
+	}
+
+	windows := compute.VirtualMachineExtensionInstanceView{
+		Statuses: &[]compute.InstanceViewStatus{status("ProvisioningState/succeeded", "Command execution finished")},
+		Substatuses: &[]compute.InstanceViewStatus{
+			status("ComponentStatus/StdOut/succeeded", "Bootstrap complete"),
+			status("ComponentStatus/StdErr/succeeded", ""),
+		},
+	}
+	require.Equal(t, VMRunCommandResult{StdOut: "Bootstrap complete", Succeeded: true}, parseExtensionInstanceView(windows))
+
+	linux := compute.VirtualMachineExtensionInstanceView{
+		Statuses: &[]compute.InstanceViewStatus{status("ProvisioningState/succeeded", "Enable succeeded: \n[stdout]\nBootstrap complete\n\n[stderr]\n")},
+	}
+	require.Equal(t, VMRunCommandResult{StdOut: "Bootstrap complete", Succeeded: true}, parseExtensionInstanceView(linux))
+}
+
+func TestGetVirtualMachineExtensionsE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := GetVirtualMachineExtensionsE(t, rgName, vmName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetVirtualMachineExtensionOutputE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	vmExtName := ""
+	subID := ""
+
+	_, err := GetVirtualMachineExtensionOutputE(t, rgName, vmName, vmExtName, subID)
+
+	require.Error(t, err)
+}
@@ -0,0 +1,464 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SharedImageGallery describes an Azure Shared Image Gallery
+type SharedImageGallery struct {
+	ID                string
+	Name              string
+	Description       string
+	UniqueName        string
+	ProvisioningState string
+}
+
+// GalleryImageDefinition describes an image definition in a Shared Image Gallery
+type GalleryImageDefinition struct {
+	ID                string
+	Name              string
+	Description       string
+	Publisher         string
+	Offer             string
+	Sku               string
+	OsType            string
+	OsState           string
+	HyperVGeneration  string
+	Architecture      string
+	ProvisioningState string
+
+	// Features maps each image feature name to its value, e.g. "SecurityType" -> "TrustedLaunch"
+	Features map[string]string
+
+	// EndOfLifeDate is the zero time when no end of life date is set
+	EndOfLifeDate time.Time
+}
+
+// GalleryImageVersion describes a version of a Shared Image Gallery image and its replication
+type GalleryImageVersion struct {
+	ID                string
+	Name              string
+	ProvisioningState string
+	ReplicaCount      int32
+	ExcludeFromLatest bool
+
+	// PublishedDate and EndOfLifeDate are the zero time when not set
+	PublishedDate time.Time
+	EndOfLifeDate time.Time
+
+	TargetRegions []GalleryTargetRegion
+
+	// ReplicationState is the aggregated replication state across all target regions, e.g. Completed or InProgress.
+	// It is only populated when the version is looked up individually.
+	ReplicationState    string
+	RegionalReplication []GalleryRegionalReplication
+}
+
+// GalleryTargetRegion describes a region a Shared Image Gallery image version is published to
+type GalleryTargetRegion struct {
+	Name               string
+	ReplicaCount       int32
+	StorageAccountType string
+}
+
+// GalleryRegionalReplication describes the replication progress of an image version in a single region
+type GalleryRegionalReplication struct {
+	Region   string
+	State    string
+	Progress int32
+	Details  string
+}
+
+// GetGalleriesClient is a helper function that will setup an Azure Shared Image Gallery client on your behalf
+func GetGalleriesClient(subscriptionID string) (*compute.GalleriesClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Gallery client
+	galleryClient := compute.NewGalleriesClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	galleryClient.Authorizer = *authorizer
+
+	return &galleryClient, nil
+}
+
+// GetGalleryImagesClient is a helper function that will setup an Azure Gallery Image Definition client on your behalf
+func GetGalleryImagesClient(subscriptionID string) (*compute.GalleryImagesClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Gallery Image client
+	imageClient := compute.NewGalleryImagesClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	imageClient.Authorizer = *authorizer
+
+	return &imageClient, nil
+}
+
+// GetGalleryImageVersionsClient is a helper function that will setup an Azure Gallery Image Version client on your behalf
+func GetGalleryImageVersionsClient(subscriptionID string) (*compute.GalleryImageVersionsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Gallery Image Version client
+	versionClient := compute.NewGalleryImageVersionsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	versionClient.Authorizer = *authorizer
+
+	return &versionClient, nil
+}
+
+// GetSharedImageGallery gets the details of a Shared Image Gallery by Name
+func GetSharedImageGallery(t *testing.T, resGroupName string, galleryName string, subscriptionID string) SharedImageGallery {
+	gallery, err := GetSharedImageGalleryE(t, resGroupName, galleryName, subscriptionID)
+	require.NoError(t, err)
+
+	return gallery
+}
+
+// GetSharedImageGalleryE gets the details of a Shared Image Gallery by Name
+func GetSharedImageGalleryE(t *testing.T, resGroupName string, galleryName string, subscriptionID string) (SharedImageGallery, error) {
+	result := SharedImageGallery{}
+
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return result, err
+	}
+
+	// Create a Gallery client
+	galleryClient, err := GetGalleriesClient(subscriptionID)
+	if err != nil {
+		return result, err
+	}
+
+	// Get the details of the gallery
+	gallery, err := galleryClient.Get(context.Background(), resGroupName, galleryName, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	if gallery.ID != nil {
+		result.ID = *gallery.ID
+	}
+	if gallery.Name != nil {
+		result.Name = *gallery.Name
+	}
+	if props := gallery.GalleryProperties; props != nil {
+		result.ProvisioningState = string(props.ProvisioningState)
+		if props.Description != nil {
+			result.Description = *props.Description
+		}
+		if props.Identifier != nil && props.Identifier.UniqueName != nil {
+			result.UniqueName = *props.Identifier.UniqueName
+		}
+	}
+
+	return result, nil
+}
+
+// GetGalleryImageDefinition gets the details of an image definition in a Shared Image Gallery
+func GetGalleryImageDefinition(t *testing.T, resGroupName string, galleryName string, imageName string, subscriptionID string) GalleryImageDefinition {
+	image, err := GetGalleryImageDefinitionE(t, resGroupName, galleryName, imageName, subscriptionID)
+	require.NoError(t, err)
+
+	return image
+}
+
+// GetGalleryImageDefinitionE gets the details of an image definition in a Shared Image Gallery
+func GetGalleryImageDefinitionE(t *testing.T, resGroupName string, galleryName string, imageName string, subscriptionID string) (GalleryImageDefinition, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return GalleryImageDefinition{}, err
+	}
+
+	// Create a Gallery Image client
+	imageClient, err := GetGalleryImagesClient(subscriptionID)
+	if err != nil {
+		return GalleryImageDefinition{}, err
+	}
+
+	// Get the details of the image definition
+	image, err := imageClient.Get(context.Background(), resGroupName, galleryName, imageName)
+	if err != nil {
+		return GalleryImageDefinition{}, err
+	}
+
+	return newGalleryImageDefinition(image), nil
+}
+
+// GetGalleryImageVersion gets the details and replication status of a version of a Shared Image Gallery image
+func GetGalleryImageVersion(t *testing.T, resGroupName string, galleryName string, imageName string, versionName string, subscriptionID string) GalleryImageVersion {
+	version, err := GetGalleryImageVersionE(t, resGroupName, galleryName, imageName, versionName, subscriptionID)
+	require.NoError(t, err)
+
+	return version
+}
+
+// GetGalleryImageVersionE gets the details and replication status of a version of a Shared Image Gallery image
+func GetGalleryImageVersionE(t *testing.T, resGroupName string, galleryName string, imageName string, versionName string, subscriptionID string) (GalleryImageVersion, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return GalleryImageVersion{}, err
+	}
+
+	// Create a Gallery Image Version client
+	versionClient, err := GetGalleryImageVersionsClient(subscriptionID)
+	if err != nil {
+		return GalleryImageVersion{}, err
+	}
+
+	// Get the details of the image version, including its replication status
+	version, err := versionClient.Get(context.Background(), resGroupName, galleryName, imageName, versionName, compute.ReplicationStatusTypesReplicationStatus)
+	if err != nil {
+		return GalleryImageVersion{}, err
+	}
+
+	return newGalleryImageVersion(version), nil
+}
+
+// GetGalleryImageVersions gets every version of a Shared Image Gallery image. Replication status is not included; use
+// GetGalleryImageVersion for that.
+func GetGalleryImageVersions(t *testing.T, resGroupName string, galleryName string, imageName string, subscriptionID string) []GalleryImageVersion {
+	versions, err := GetGalleryImageVersionsE(t, resGroupName, galleryName, imageName, subscriptionID)
+	require.NoError(t, err)
+
+	return versions
+}
+
+// GetGalleryImageVersionsE gets every version of a Shared Image Gallery image. Replication status is not included; use
+// GetGalleryImageVersionE for that.
+func GetGalleryImageVersionsE(t *testing.T, resGroupName string, galleryName string, imageName string, subscriptionID string) ([]GalleryImageVersion, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Gallery Image Version client
+	versionClient, err := GetGalleryImageVersionsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := versionClient.ListByGalleryImageComplete(context.Background(), resGroupName, galleryName, imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []GalleryImageVersion{}
+	for iterator.NotDone() {
+		versions = append(versions, newGalleryImageVersion(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// AssertGalleryImageVersionReplicated checks that the given image version has completed replication to every one of
+// the given regions. Regions may be given either as names (eastus) or display names (East US). When no regions are
+// given, the target regions of the image version are checked.
+func AssertGalleryImageVersionReplicated(t *testing.T, version GalleryImageVersion, regions []string) {
+	if len(regions) == 0 && len(version.TargetRegions) == 0 {
+		assert.Fail(t, "No regions to check", "Image version %s has no target regions", version.Name)
+		return
+	}
+
+	missing := getUnreplicatedRegions(version, regions)
+
+	assert.Empty(t, missing, "Image version %s has not completed replication to regions %v", version.Name, missing)
+}
+
+// getUnreplicatedRegions returns the regions in which the given image version has not completed replication. When no
+// regions are given, the target regions of the image version are checked.
+func getUnreplicatedRegions(version GalleryImageVersion, regions []string) []string {
+	if len(regions) == 0 {
+		for _, target := range version.TargetRegions {
+			regions = append(regions, target.Name)
+		}
+	}
+
+	completed := make(map[string]bool)
+	for _, replication := range version.RegionalReplication {
+		if replication.State == string(compute.ReplicationStateCompleted) {
+			completed[normalizeRegionName(replication.Region)] = true
+		}
+	}
+
+	missing := []string{}
+	for _, region := range regions {
+		if !completed[normalizeRegionName(region)] {
+			missing = append(missing, region)
+		}
+	}
+
+	return missing
+}
+
+// normalizeRegionName turns a region display name such as "East US" into its name, e.g. "eastus"
+func normalizeRegionName(region string) string {
+	return strings.ToLower(strings.Replace(region, " ", "", -1))
+}
+
+// newGalleryImageDefinition converts a gallery image returned by the API into a GalleryImageDefinition
+func newGalleryImageDefinition(image compute.GalleryImage) GalleryImageDefinition {
+	result := GalleryImageDefinition{Features: make(map[string]string)}
+
+	if image.ID != nil {
+		result.ID = *image.ID
+	}
+	if image.Name != nil {
+		result.Name = *image.Name
+	}
+
+	props := image.GalleryImageProperties
+	if props == nil {
+		return result
+	}
+
+	result.OsType = string(props.OsType)
+	result.OsState = string(props.OsState)
+	result.HyperVGeneration = string(props.HyperVGeneration)
+	result.Architecture = string(props.Architecture)
+	result.ProvisioningState = string(props.ProvisioningState)
+
+	if props.Description != nil {
+		result.Description = *props.Description
+	}
+	if props.EndOfLifeDate != nil {
+		result.EndOfLifeDate = props.EndOfLifeDate.Time
+	}
+	if props.Identifier != nil {
+		if props.Identifier.Publisher != nil {
+			result.Publisher = *props.Identifier.Publisher
+		}
+		if props.Identifier.Offer != nil {
+			result.Offer = *props.Identifier.Offer
+		}
+		if props.Identifier.Sku != nil {
+			result.Sku = *props.Identifier.Sku
+		}
+	}
+	if props.Features != nil {
+		for _, feature := range *props.Features {
+			if feature.Name != nil && feature.Value != nil {
+				result.Features[*feature.Name] = *feature.Value
+			}
+		}
+	}
+
+	return result
+}
+
+// newGalleryImageVersion converts a gallery image version returned by the API into a GalleryImageVersion
+func newGalleryImageVersion(version compute.GalleryImageVersion) GalleryImageVersion {
+	result := GalleryImageVersion{
+		TargetRegions:       []GalleryTargetRegion{},
+		RegionalReplication: []GalleryRegionalReplication{},
+	}
+
+	if version.ID != nil {
+		result.ID = *version.ID
+	}
+	if version.Name != nil {
+		result.Name = *version.Name
+	}
+
+	props := version.GalleryImageVersionProperties
+	if props == nil {
+		return result
+	}
+
+	result.ProvisioningState = string(props.ProvisioningState)
+
+	if publishing := props.PublishingProfile; publishing != nil {
+		if publishing.ReplicaCount != nil {
+			result.ReplicaCount = *publishing.ReplicaCount
+		}
+		if publishing.ExcludeFromLatest != nil {
+			result.ExcludeFromLatest = *publishing.ExcludeFromLatest
+		}
+		if publishing.PublishedDate != nil {
+			result.PublishedDate = publishing.PublishedDate.Time
+		}
+		if publishing.EndOfLifeDate != nil {
+			result.EndOfLifeDate = publishing.EndOfLifeDate.Time
+		}
+		if publishing.TargetRegions != nil {
+			for _, region := range *publishing.TargetRegions {
+				target := GalleryTargetRegion{StorageAccountType: string(region.StorageAccountType)}
+				if region.Name != nil {
+					target.Name = *region.Name
+				}
+				if region.RegionalReplicaCount != nil {
+					target.ReplicaCount = *region.RegionalReplicaCount
+				}
+				result.TargetRegions = append(result.TargetRegions, target)
+			}
+		}
+	}
+
+	if status := props.ReplicationStatus; status != nil {
+		result.ReplicationState = string(status.AggregatedState)
+
+		if status.Summary != nil {
+			for _, summary := range *status.Summary {
+				replication := GalleryRegionalReplication{State: string(summary.State)}
+				if summary.Region != nil {
+					replication.Region = *summary.Region
+				}
+				if summary.Progress != nil {
+					replication.Progress = *summary.Progress
+				}
+				if summary.Details != nil {
+					replication.Details = *summary.Details
+				}
+				result.RegionalReplication = append(result.RegionalReplication, replication)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,34 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUnreplicatedRegions(t *testing.T) {
+	t.Parallel()
+
+	version := GalleryImageVersion{
+		Name: "1.0.0",
+		TargetRegions: []GalleryTargetRegion{
+			{Name: "East US", ReplicaCount: 1},
+			{Name: "West Europe", ReplicaCount: 1},
+		},
+		RegionalReplication: []GalleryRegionalReplication{
+			{Region: "East US", State: "Completed", Progress: 100},
+			{Region: "West Europe", State: "Replicating", Progress: 40},
+		},
+	}
+
+	require.Equal(t, []string{}, getUnreplicatedRegions(version, []string{"eastus"}))
+	require.Equal(t, []string{"westeurope", "southeastasia"}, getUnreplicatedRegions(version, []string{"eastus", "westeurope", "southeastasia"}))
+	require.Equal(t, []string{"West Europe"}, getUnreplicatedRegions(version, nil))
+
+	AssertGalleryImageVersionReplicated(t, version, []string{"East US"})
+}
@@ -0,0 +1,320 @@
+package azure
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// VMImageReference describes the image a Virtual Machine was built from
+type VMImageReference struct {
+	Publisher string
+	Offer     string
+	Sku       string
+
+	// Version is the version requested at deploy time, which may be "latest"
+	Version string
+
+	// ExactVersion is the version "latest" resolved to when the Virtual Machine was deployed
+	ExactVersion string
+
+	// ID is the resource ID of the custom or Shared Image Gallery image, if the Virtual Machine was not built from a
+	// marketplace image
+	ID string
+
+	SharedGalleryImageID    string
+	CommunityGalleryImageID string
+}
+
+// IsMarketplaceImage returns true when the image reference points to a platform or marketplace image
+func (image VMImageReference) IsMarketplaceImage() bool {
+	return image.Publisher != "" && image.Offer != "" && image.Sku != ""
+}
+
+// GetVirtualMachineImagesClient is a helper function that will setup an Azure Virtual Machine Images client on your behalf
+func GetVirtualMachineImagesClient(subscriptionID string) (*compute.VirtualMachineImagesClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VM Images client
+	imageClient := compute.NewVirtualMachineImagesClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	imageClient.Authorizer = *authorizer
+
+	return &imageClient, nil
+}
+
+// GetImageReferenceOfVirtualMachine gets the image reference the given Virtual Machine was built from
+func GetImageReferenceOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) VMImageReference {
+	image, err := GetImageReferenceOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return image
+}
+
+// GetImageReferenceOfVirtualMachineE gets the image reference the given Virtual Machine was built from
+func GetImageReferenceOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (VMImageReference, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return VMImageReference{}, err
+	}
+
+	if vm.VirtualMachineProperties == nil || vm.StorageProfile == nil || vm.StorageProfile.ImageReference == nil {
+		return VMImageReference{}, nil
+	}
+
+	return newVMImageReference(*vm.StorageProfile.ImageReference), nil
+}
+
+// GetVirtualMachineImagePublishers gets the names of the image publishers available in the given region
+func GetVirtualMachineImagePublishers(t *testing.T, location string, subscriptionID string) []string {
+	publishers, err := GetVirtualMachineImagePublishersE(t, location, subscriptionID)
+	require.NoError(t, err)
+
+	return publishers
+}
+
+// GetVirtualMachineImagePublishersE gets the names of the image publishers available in the given region
+func GetVirtualMachineImagePublishersE(t *testing.T, location string, subscriptionID string) ([]string, error) {
+	// Create a VM Images client
+	imageClient, err := GetVirtualMachineImagesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	publishers, err := imageClient.ListPublishers(context.Background(), location)
+	if err != nil {
+		return nil, err
+	}
+
+	return imageResourceNames(publishers), nil
+}
+
+// GetVirtualMachineImageOffers gets the names of the offers of the given publisher in the given region
+func GetVirtualMachineImageOffers(t *testing.T, location string, publisher string, subscriptionID string) []string {
+	offers, err := GetVirtualMachineImageOffersE(t, location, publisher, subscriptionID)
+	require.NoError(t, err)
+
+	return offers
+}
+
+// GetVirtualMachineImageOffersE gets the names of the offers of the given publisher in the given region
+func GetVirtualMachineImageOffersE(t *testing.T, location string, publisher string, subscriptionID string) ([]string, error) {
+	// Create a VM Images client
+	imageClient, err := GetVirtualMachineImagesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	offers, err := imageClient.ListOffers(context.Background(), location, publisher)
+	if err != nil {
+		return nil, err
+	}
+
+	return imageResourceNames(offers), nil
+}
+
+// GetVirtualMachineImageSkus gets the names of the SKUs of the given publisher and offer in the given region
+func GetVirtualMachineImageSkus(t *testing.T, location string, publisher string, offer string, subscriptionID string) []string {
+	skus, err := GetVirtualMachineImageSkusE(t, location, publisher, offer, subscriptionID)
+	require.NoError(t, err)
+
+	return skus
+}
+
+// GetVirtualMachineImageSkusE gets the names of the SKUs of the given publisher and offer in the given region
+func GetVirtualMachineImageSkusE(t *testing.T, location string, publisher string, offer string, subscriptionID string) ([]string, error) {
+	// Create a VM Images client
+	imageClient, err := GetVirtualMachineImagesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	skus, err := imageClient.ListSkus(context.Background(), location, publisher, offer)
+	if err != nil {
+		return nil, err
+	}
+
+	return imageResourceNames(skus), nil
+}
+
+// GetVirtualMachineImageVersions gets the versions of the given image SKU in the given region, sorted from oldest to newest
+func GetVirtualMachineImageVersions(t *testing.T, location string, publisher string, offer string, sku string, subscriptionID string) []string {
+	versions, err := GetVirtualMachineImageVersionsE(t, location, publisher, offer, sku, subscriptionID)
+	require.NoError(t, err)
+
+	return versions
+}
+
+// GetVirtualMachineImageVersionsE gets the versions of the given image SKU in the given region, sorted from oldest to newest
+func GetVirtualMachineImageVersionsE(t *testing.T, location string, publisher string, offer string, sku string, subscriptionID string) ([]string, error) {
+	// Create a VM Images client
+	imageClient, err := GetVirtualMachineImagesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := imageClient.List(context.Background(), location, publisher, offer, sku, "", nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := imageResourceNames(images)
+	sortImageVersions(versions)
+
+	return versions, nil
+}
+
+// GetVersionsBehindLatestForVirtualMachine gets how many image versions have been published for the marketplace image
+// of the given Virtual Machine since the version it was built from
+func GetVersionsBehindLatestForVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) int {
+	behind, err := GetVersionsBehindLatestForVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return behind
+}
+
+// GetVersionsBehindLatestForVirtualMachineE gets how many image versions have been published for the marketplace image
+// of the given Virtual Machine since the version it was built from
+func GetVersionsBehindLatestForVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (int, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	image := VMImageReference{}
+	if vm.VirtualMachineProperties != nil && vm.StorageProfile != nil && vm.StorageProfile.ImageReference != nil {
+		image = newVMImageReference(*vm.StorageProfile.ImageReference)
+	}
+	if !image.IsMarketplaceImage() || image.ExactVersion == "" {
+		return 0, MarketplaceImageNotFound{VMName: vmName}
+	}
+
+	if vm.Location == nil {
+		return 0, VMLocationNotFound{VMName: vmName}
+	}
+
+	versions, err := GetVirtualMachineImageVersionsE(t, *vm.Location, image.Publisher, image.Offer, image.Sku, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return countNewerImageVersions(image.ExactVersion, versions), nil
+}
+
+// AssertVirtualMachineImageIsRecent checks that the given Virtual Machine was built from a marketplace image that is no
+// more than maxVersionsBehind versions behind the latest published version
+func AssertVirtualMachineImageIsRecent(t *testing.T, resGroupName string, vmName string, maxVersionsBehind int, subscriptionID string) {
+	behind, err := GetVersionsBehindLatestForVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	assert.True(t, behind <= maxVersionsBehind, "Virtual Machine %s image is %d versions behind latest, expected at most %d", vmName, behind, maxVersionsBehind)
+}
+
+// newVMImageReference converts an image reference returned by the API into a VMImageReference
+func newVMImageReference(ref compute.ImageReference) VMImageReference {
+	image := VMImageReference{}
+
+	if ref.Publisher != nil {
+		image.Publisher = *ref.Publisher
+	}
+	if ref.Offer != nil {
+		image.Offer = *ref.Offer
+	}
+	if ref.Sku != nil {
+		image.Sku = *ref.Sku
+	}
+	if ref.Version != nil {
+		image.Version = *ref.Version
+	}
+	if ref.ExactVersion != nil {
+		image.ExactVersion = *ref.ExactVersion
+	}
+	if ref.ID != nil {
+		image.ID = *ref.ID
+	}
+	if ref.SharedGalleryImageID != nil {
+		image.SharedGalleryImageID = *ref.SharedGalleryImageID
+	}
+	if ref.CommunityGalleryImageID != nil {
+		image.CommunityGalleryImageID = *ref.CommunityGalleryImageID
+	}
+
+	return image
+}
+
+// imageResourceNames collects the names from a Virtual Machine Images list response
+func imageResourceNames(resources compute.ListVirtualMachineImageResource) []string {
+	names := []string{}
+	if resources.Value == nil {
+		return names
+	}
+
+	for _, resource := range *resources.Value {
+		if resource.Name != nil {
+			names = append(names, *resource.Name)
+		}
+	}
+
+	return names
+}
+
+// sortImageVersions sorts image versions in Major.Minor.Build format from oldest to newest
+func sortImageVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareImageVersions(versions[i], versions[j]) < 0
+	})
+}
+
+// countNewerImageVersions counts how many of the given versions are newer than current
+func countNewerImageVersions(current string, versions []string) int {
+	newer := 0
+	for _, version := range versions {
+		if compareImageVersions(version, current) > 0 {
+			newer++
+		}
+	}
+
+	return newer
+}
+
+// compareImageVersions compares two image versions in Major.Minor.Build format numerically, returning -1, 0 or 1
+func compareImageVersions(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int64
+		if i < len(aParts) {
+			aPart, _ = strconv.ParseInt(aParts[i], 10, 64)
+		}
+		if i < len(bParts) {
+			bPart, _ = strconv.ParseInt(bParts[i], 10, 64)
+		}
+
+		if aPart < bPart {
+			return -1
+		}
+		if aPart > bPart {
+			return 1
+		}
+	}
+
+	return 0
+}
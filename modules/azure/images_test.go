@@ -0,0 +1,44 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortImageVersions(t *testing.T) {
+	t.Parallel()
+
+	versions := []string{"16.04.202004290", "16.04.201910100", "16.04.202002180", "16.04.20200218"}
+	sortImageVersions(versions)
+
+	require.Equal(t, []string{"16.04.20200218", "16.04.201910100", "16.04.202002180", "16.04.202004290"}, versions)
+}
+
+func TestCountNewerImageVersions(t *testing.T) {
+	t.Parallel()
+
+	versions := []string{"18.04.201910100", "18.04.202002180", "18.04.202004290"}
+
+	tests := []struct {
+		name    string
+		current string
+		want    int
+	}{
+		{name: "latest", current: "18.04.202004290", want: 0},
+		{name: "oneBehind", current: "18.04.202002180", want: 1},
+		{name: "oldest", current: "18.04.201910100", want: 2},
+		{name: "notListed", current: "18.04.201801010", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, countNewerImageVersions(tt.current, versions))
+		})
+	}
+}
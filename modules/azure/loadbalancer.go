@@ -0,0 +1,528 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// LoadBalancer describes an Azure Load Balancer and its frontends, backend pools, probes and rules
+type LoadBalancer struct {
+	ID   string
+	Name string
+	Sku  string
+
+	Frontends       []LoadBalancerFrontend
+	BackendPools    []LoadBalancerBackendPool
+	Probes          []LoadBalancerProbe
+	Rules           []LoadBalancerRule
+	InboundNatRules []LoadBalancerInboundNatRule
+	OutboundRules   []LoadBalancerOutboundRule
+}
+
+// LoadBalancerFrontend describes a frontend IP configuration of a Load Balancer. A public frontend has a
+// PublicIPAddressID, while an internal frontend has a private IP in a subnet.
+type LoadBalancerFrontend struct {
+	ID                        string
+	Name                      string
+	PrivateIPAddress          string
+	PrivateIPAllocationMethod string
+	SubnetID                  string
+	PublicIPAddressID         string
+	Zones                     []string
+}
+
+// LoadBalancerBackendPool describes a backend pool of a Load Balancer. NIC based pools list the IP configurations of
+// their members, while IP based pools list the member IP addresses.
+type LoadBalancerBackendPool struct {
+	ID                 string
+	Name               string
+	IPConfigurationIDs []string
+	IPAddresses        []string
+}
+
+// LoadBalancerProbe describes a health probe of a Load Balancer
+type LoadBalancerProbe struct {
+	ID                string
+	Name              string
+	Protocol          string
+	Port              int32
+	RequestPath       string
+	IntervalInSeconds int32
+	NumberOfProbes    int32
+}
+
+// LoadBalancerRule describes a load balancing rule of a Load Balancer
+type LoadBalancerRule struct {
+	ID                        string
+	Name                      string
+	Protocol                  string
+	FrontendPort              int32
+	BackendPort               int32
+	FrontendIPConfigurationID string
+	BackendPoolID             string
+	ProbeID                   string
+	LoadDistribution          string
+	IdleTimeoutInMinutes      int32
+	EnableFloatingIP          bool
+	EnableTCPReset            bool
+	DisableOutboundSnat       bool
+}
+
+// LoadBalancerInboundNatRule describes an inbound NAT rule of a Load Balancer, mapping a frontend port (or port range
+// for rules targeting a backend pool) to a backend port
+type LoadBalancerInboundNatRule struct {
+	ID                        string
+	Name                      string
+	Protocol                  string
+	FrontendPort              int32
+	FrontendPortRangeStart    int32
+	FrontendPortRangeEnd      int32
+	BackendPort               int32
+	FrontendIPConfigurationID string
+
+	// BackendIPConfigurationID is the NIC IP configuration a single instance rule maps to, while BackendPoolID is set
+	// for rules mapping a port range onto a backend pool
+	BackendIPConfigurationID string
+	BackendPoolID            string
+}
+
+// LoadBalancerOutboundRule describes an outbound (SNAT) rule of a Load Balancer
+type LoadBalancerOutboundRule struct {
+	ID                         string
+	Name                       string
+	Protocol                   string
+	AllocatedOutboundPorts     int32
+	FrontendIPConfigurationIDs []string
+	BackendPoolID              string
+	IdleTimeoutInMinutes       int32
+	EnableTCPReset             bool
+}
+
+// GetLoadBalancersClient is a helper function that will setup an Azure Load Balancer client on your behalf
+func GetLoadBalancersClient(subscriptionID string) (*network.LoadBalancersClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Load Balancer client
+	lbClient := network.NewLoadBalancersClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	lbClient.Authorizer = *authorizer
+
+	return &lbClient, nil
+}
+
+// GetLoadBalancer gets the frontends, backend pools, probes and rules of an Azure Load Balancer by Name
+func GetLoadBalancer(t *testing.T, resGroupName string, lbName string, subscriptionID string) LoadBalancer {
+	lb, err := GetLoadBalancerE(t, resGroupName, lbName, subscriptionID)
+	require.NoError(t, err)
+
+	return lb
+}
+
+// GetLoadBalancerE gets the frontends, backend pools, probes and rules of an Azure Load Balancer by Name
+func GetLoadBalancerE(t *testing.T, resGroupName string, lbName string, subscriptionID string) (LoadBalancer, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return LoadBalancer{}, err
+	}
+
+	// Create a Load Balancer client
+	lbClient, err := GetLoadBalancersClient(subscriptionID)
+	if err != nil {
+		return LoadBalancer{}, err
+	}
+
+	// Get the details of the Load Balancer
+	lb, err := lbClient.Get(context.Background(), resGroupName, lbName, "")
+	if err != nil {
+		return LoadBalancer{}, err
+	}
+
+	return newLoadBalancer(lb), nil
+}
+
+// GetLoadBalancerBackendPool gets a backend pool of an Azure Load Balancer by Name
+func GetLoadBalancerBackendPool(t *testing.T, resGroupName string, lbName string, poolName string, subscriptionID string) LoadBalancerBackendPool {
+	pool, err := GetLoadBalancerBackendPoolE(t, resGroupName, lbName, poolName, subscriptionID)
+	require.NoError(t, err)
+
+	return pool
+}
+
+// GetLoadBalancerBackendPoolE gets a backend pool of an Azure Load Balancer by Name
+func GetLoadBalancerBackendPoolE(t *testing.T, resGroupName string, lbName string, poolName string, subscriptionID string) (LoadBalancerBackendPool, error) {
+	lb, err := GetLoadBalancerE(t, resGroupName, lbName, subscriptionID)
+	if err != nil {
+		return LoadBalancerBackendPool{}, err
+	}
+
+	for _, pool := range lb.BackendPools {
+		if strings.EqualFold(pool.Name, poolName) {
+			return pool, nil
+		}
+	}
+
+	return LoadBalancerBackendPool{}, LoadBalancerBackendPoolNotFound{LoadBalancerName: lbName, PoolName: poolName}
+}
+
+// AssertVirtualMachineInBackendPool checks that a NIC of the given Virtual Machine is a member of the named backend pool
+// of a Load Balancer in the same resource group, either through its IP configuration or its private IP address
+func AssertVirtualMachineInBackendPool(t *testing.T, resGroupName string, vmName string, lbName string, poolName string, subscriptionID string) {
+	pool := GetLoadBalancerBackendPool(t, resGroupName, lbName, poolName, subscriptionID)
+	nics := GetNetworkInterfacesOfVirtualMachine(t, resGroupName, vmName, subscriptionID)
+
+	assert.True(t, isNetworkInterfaceInBackendPool(nics, pool), "Check Virtual Machine %s is a member of backend pool %s of Load Balancer %s", vmName, poolName, lbName)
+}
+
+// isNetworkInterfaceInBackendPool checks whether an IP configuration of any of the given NICs is a member of the pool
+func isNetworkInterfaceInBackendPool(nics []NetworkInterface, pool LoadBalancerBackendPool) bool {
+	for _, nic := range nics {
+		for _, ipConfig := range nic.IPConfigurations {
+			for _, id := range pool.IPConfigurationIDs {
+				if strings.EqualFold(id, ipConfig.ID) {
+					return true
+				}
+			}
+			for _, address := range pool.IPAddresses {
+				if ipConfig.PrivateIPAddress != "" && address == ipConfig.PrivateIPAddress {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// getSubResourceID returns the ID of a sub resource reference, or empty if there is none
+func getSubResourceID(resource *network.SubResource) string {
+	if resource == nil || resource.ID == nil {
+		return ""
+	}
+
+	return *resource.ID
+}
+
+// getSubResourceIDs returns the IDs of a list of sub resource references
+func getSubResourceIDs(resources *[]network.SubResource) []string {
+	ids := []string{}
+
+	if resources == nil {
+		return ids
+	}
+	for _, resource := range *resources {
+		if resource.ID != nil {
+			ids = append(ids, *resource.ID)
+		}
+	}
+
+	return ids
+}
+
+// newLoadBalancer converts a Load Balancer returned by the API into a LoadBalancer
+func newLoadBalancer(lb network.LoadBalancer) LoadBalancer {
+	result := LoadBalancer{
+		Frontends:       []LoadBalancerFrontend{},
+		BackendPools:    []LoadBalancerBackendPool{},
+		Probes:          []LoadBalancerProbe{},
+		Rules:           []LoadBalancerRule{},
+		InboundNatRules: []LoadBalancerInboundNatRule{},
+		OutboundRules:   []LoadBalancerOutboundRule{},
+	}
+
+	if lb.ID != nil {
+		result.ID = *lb.ID
+	}
+	if lb.Name != nil {
+		result.Name = *lb.Name
+	}
+	if lb.Sku != nil {
+		result.Sku = string(lb.Sku.Name)
+	}
+
+	props := lb.LoadBalancerPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	if props.FrontendIPConfigurations != nil {
+		for _, frontend := range *props.FrontendIPConfigurations {
+			result.Frontends = append(result.Frontends, newLoadBalancerFrontend(frontend))
+		}
+	}
+	if props.BackendAddressPools != nil {
+		for _, pool := range *props.BackendAddressPools {
+			result.BackendPools = append(result.BackendPools, newLoadBalancerBackendPool(pool))
+		}
+	}
+	if props.Probes != nil {
+		for _, probe := range *props.Probes {
+			result.Probes = append(result.Probes, newLoadBalancerProbe(probe))
+		}
+	}
+	if props.LoadBalancingRules != nil {
+		for _, rule := range *props.LoadBalancingRules {
+			result.Rules = append(result.Rules, newLoadBalancerRule(rule))
+		}
+	}
+	if props.InboundNatRules != nil {
+		for _, rule := range *props.InboundNatRules {
+			result.InboundNatRules = append(result.InboundNatRules, newLoadBalancerInboundNatRule(rule))
+		}
+	}
+	if props.OutboundRules != nil {
+		for _, rule := range *props.OutboundRules {
+			result.OutboundRules = append(result.OutboundRules, newLoadBalancerOutboundRule(rule))
+		}
+	}
+
+	return result
+}
+
+// newLoadBalancerFrontend converts a frontend IP configuration returned by the API into a LoadBalancerFrontend
+func newLoadBalancerFrontend(frontend network.FrontendIPConfiguration) LoadBalancerFrontend {
+	result := LoadBalancerFrontend{Zones: []string{}}
+
+	if frontend.ID != nil {
+		result.ID = *frontend.ID
+	}
+	if frontend.Name != nil {
+		result.Name = *frontend.Name
+	}
+	if frontend.Zones != nil {
+		result.Zones = *frontend.Zones
+	}
+
+	props := frontend.FrontendIPConfigurationPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.PrivateIPAllocationMethod = string(props.PrivateIPAllocationMethod)
+
+	if props.PrivateIPAddress != nil {
+		result.PrivateIPAddress = *props.PrivateIPAddress
+	}
+	if props.Subnet != nil && props.Subnet.ID != nil {
+		result.SubnetID = *props.Subnet.ID
+	}
+	if props.PublicIPAddress != nil && props.PublicIPAddress.ID != nil {
+		result.PublicIPAddressID = *props.PublicIPAddress.ID
+	}
+
+	return result
+}
+
+// newLoadBalancerBackendPool converts a backend pool returned by the API into a LoadBalancerBackendPool
+func newLoadBalancerBackendPool(pool network.BackendAddressPool) LoadBalancerBackendPool {
+	result := LoadBalancerBackendPool{IPConfigurationIDs: []string{}, IPAddresses: []string{}}
+
+	if pool.ID != nil {
+		result.ID = *pool.ID
+	}
+	if pool.Name != nil {
+		result.Name = *pool.Name
+	}
+
+	props := pool.BackendAddressPoolPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	seen := make(map[string]bool)
+	addIPConfigurationID := func(id string) {
+		if id != "" && !seen[strings.ToLower(id)] {
+			seen[strings.ToLower(id)] = true
+			result.IPConfigurationIDs = append(result.IPConfigurationIDs, id)
+		}
+	}
+
+	if props.BackendIPConfigurations != nil {
+		for _, ipConfig := range *props.BackendIPConfigurations {
+			if ipConfig.ID != nil {
+				addIPConfigurationID(*ipConfig.ID)
+			}
+		}
+	}
+
+	// NIC based pools also list their members as backend addresses referring to the NIC IP configuration
+	if props.LoadBalancerBackendAddresses != nil {
+		for _, address := range *props.LoadBalancerBackendAddresses {
+			if address.LoadBalancerBackendAddressPropertiesFormat == nil {
+				continue
+			}
+			if address.IPAddress != nil && *address.IPAddress != "" {
+				result.IPAddresses = append(result.IPAddresses, *address.IPAddress)
+			}
+			addIPConfigurationID(getSubResourceID(address.NetworkInterfaceIPConfiguration))
+		}
+	}
+
+	return result
+}
+
+// newLoadBalancerProbe converts a health probe returned by the API into a LoadBalancerProbe
+func newLoadBalancerProbe(probe network.Probe) LoadBalancerProbe {
+	result := LoadBalancerProbe{}
+
+	if probe.ID != nil {
+		result.ID = *probe.ID
+	}
+	if probe.Name != nil {
+		result.Name = *probe.Name
+	}
+
+	props := probe.ProbePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.Protocol = string(props.Protocol)
+
+	if props.Port != nil {
+		result.Port = *props.Port
+	}
+	if props.RequestPath != nil {
+		result.RequestPath = *props.RequestPath
+	}
+	if props.IntervalInSeconds != nil {
+		result.IntervalInSeconds = *props.IntervalInSeconds
+	}
+	if props.NumberOfProbes != nil {
+		result.NumberOfProbes = *props.NumberOfProbes
+	}
+
+	return result
+}
+
+// newLoadBalancerRule converts a load balancing rule returned by the API into a LoadBalancerRule
+func newLoadBalancerRule(rule network.LoadBalancingRule) LoadBalancerRule {
+	result := LoadBalancerRule{}
+
+	if rule.ID != nil {
+		result.ID = *rule.ID
+	}
+	if rule.Name != nil {
+		result.Name = *rule.Name
+	}
+
+	props := rule.LoadBalancingRulePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.Protocol = string(props.Protocol)
+	result.LoadDistribution = string(props.LoadDistribution)
+	result.FrontendIPConfigurationID = getSubResourceID(props.FrontendIPConfiguration)
+	result.BackendPoolID = getSubResourceID(props.BackendAddressPool)
+	result.ProbeID = getSubResourceID(props.Probe)
+
+	if props.FrontendPort != nil {
+		result.FrontendPort = *props.FrontendPort
+	}
+	if props.BackendPort != nil {
+		result.BackendPort = *props.BackendPort
+	}
+	if props.IdleTimeoutInMinutes != nil {
+		result.IdleTimeoutInMinutes = *props.IdleTimeoutInMinutes
+	}
+	if props.EnableFloatingIP != nil {
+		result.EnableFloatingIP = *props.EnableFloatingIP
+	}
+	if props.EnableTCPReset != nil {
+		result.EnableTCPReset = *props.EnableTCPReset
+	}
+	if props.DisableOutboundSnat != nil {
+		result.DisableOutboundSnat = *props.DisableOutboundSnat
+	}
+
+	return result
+}
+
+// newLoadBalancerInboundNatRule converts an inbound NAT rule returned by the API into a LoadBalancerInboundNatRule
+func newLoadBalancerInboundNatRule(rule network.InboundNatRule) LoadBalancerInboundNatRule {
+	result := LoadBalancerInboundNatRule{}
+
+	if rule.ID != nil {
+		result.ID = *rule.ID
+	}
+	if rule.Name != nil {
+		result.Name = *rule.Name
+	}
+
+	props := rule.InboundNatRulePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.Protocol = string(props.Protocol)
+	result.FrontendIPConfigurationID = getSubResourceID(props.FrontendIPConfiguration)
+	result.BackendPoolID = getSubResourceID(props.BackendAddressPool)
+
+	if props.BackendIPConfiguration != nil && props.BackendIPConfiguration.ID != nil {
+		result.BackendIPConfigurationID = *props.BackendIPConfiguration.ID
+	}
+	if props.FrontendPort != nil {
+		result.FrontendPort = *props.FrontendPort
+	}
+	if props.FrontendPortRangeStart != nil {
+		result.FrontendPortRangeStart = *props.FrontendPortRangeStart
+	}
+	if props.FrontendPortRangeEnd != nil {
+		result.FrontendPortRangeEnd = *props.FrontendPortRangeEnd
+	}
+	if props.BackendPort != nil {
+		result.BackendPort = *props.BackendPort
+	}
+
+	return result
+}
+
+// newLoadBalancerOutboundRule converts an outbound rule returned by the API into a LoadBalancerOutboundRule
+func newLoadBalancerOutboundRule(rule network.OutboundRule) LoadBalancerOutboundRule {
+	result := LoadBalancerOutboundRule{FrontendIPConfigurationIDs: []string{}}
+
+	if rule.ID != nil {
+		result.ID = *rule.ID
+	}
+	if rule.Name != nil {
+		result.Name = *rule.Name
+	}
+
+	props := rule.OutboundRulePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.Protocol = string(props.Protocol)
+	result.FrontendIPConfigurationIDs = getSubResourceIDs(props.FrontendIPConfigurations)
+	result.BackendPoolID = getSubResourceID(props.BackendAddressPool)
+
+	if props.AllocatedOutboundPorts != nil {
+		result.AllocatedOutboundPorts = *props.AllocatedOutboundPorts
+	}
+	if props.IdleTimeoutInMinutes != nil {
+		result.IdleTimeoutInMinutes = *props.IdleTimeoutInMinutes
+	}
+	if props.EnableTCPReset != nil {
+		result.EnableTCPReset = *props.EnableTCPReset
+	}
+
+	return result
+}
@@ -0,0 +1,69 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLoadBalancerBackendPool(t *testing.T) {
+	t.Parallel()
+
+	poolName := "web-pool"
+	ipConfigID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm-nic/ipConfigurations/ipconfig1"
+	ipConfigIDUpper := "/subscriptions/sub/resourceGroups/RG/providers/Microsoft.Network/networkInterfaces/vm-nic/ipConfigurations/ipconfig1"
+	address := "10.0.1.10"
+
+	pool := newLoadBalancerBackendPool(network.BackendAddressPool{
+		Name: &poolName,
+		BackendAddressPoolPropertiesFormat: &network.BackendAddressPoolPropertiesFormat{
+			BackendIPConfigurations: &[]network.InterfaceIPConfiguration{{ID: &ipConfigID}},
+			LoadBalancerBackendAddresses: &[]network.LoadBalancerBackendAddress{
+				{LoadBalancerBackendAddressPropertiesFormat: &network.LoadBalancerBackendAddressPropertiesFormat{
+					NetworkInterfaceIPConfiguration: &network.SubResource{ID: &ipConfigIDUpper},
+				}},
+				{LoadBalancerBackendAddressPropertiesFormat: &network.LoadBalancerBackendAddressPropertiesFormat{
+					IPAddress: &address,
+				}},
+			},
+		},
+	})
+
+	require.Equal(t, LoadBalancerBackendPool{
+		Name:               "web-pool",
+		IPConfigurationIDs: []string{ipConfigID},
+		IPAddresses:        []string{"10.0.1.10"},
+	}, pool)
+}
+
+func TestIsNetworkInterfaceInBackendPool(t *testing.T) {
+	t.Parallel()
+
+	ipConfigID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm-nic/ipConfigurations/ipconfig1"
+	nics := []NetworkInterface{{IPConfigurations: []NetworkInterfaceIPConfiguration{{ID: ipConfigID, PrivateIPAddress: "10.0.1.4"}}}}
+
+	tests := []struct {
+		name string
+		pool LoadBalancerBackendPool
+		want bool
+	}{
+		{name: "NICBasedPool", pool: LoadBalancerBackendPool{IPConfigurationIDs: []string{ipConfigID}}, want: true},
+		{name: "IPBasedPool", pool: LoadBalancerBackendPool{IPAddresses: []string{"10.0.1.4"}}, want: true},
+		{name: "NotAMember", pool: LoadBalancerBackendPool{IPAddresses: []string{"10.0.1.5"}}, want: false},
+		{name: "EmptyPool", pool: LoadBalancerBackendPool{}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isNetworkInterfaceInBackendPool(nics, tt.pool)
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
 	"github.com/stretchr/testify/require"
 )
 
@@ -77,6 +77,29 @@ func GetVirtualNetworkClient(subscriptionID string) (*network.VirtualNetworksCli
 	return &vnetClient, nil
 }
 
+// GetNetworkInterfacesClient is a helper function that will setup an Azure Network Interfaces client on your behalf
+func GetNetworkInterfacesClient(subscriptionID string) (*network.InterfacesClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a NIC client
+	nicClient := network.NewInterfacesClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	nicClient.Authorizer = *authorizer
+
+	return &nicClient, nil
+}
+
 // GetSubnetsforVnet gets the list of subnets from a given Azure Virtual Network Name
 func GetSubnetsforVnet(t *testing.T, resGroupName string, vNetName string, subscriptionID string) []string {
 	subnets, err := GetSubnetsforVnetE(t, resGroupName, vNetName, subscriptionID)
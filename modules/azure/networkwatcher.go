@@ -0,0 +1,703 @@
+package azure
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// DefaultNetworkWatcherTimeout is how long to wait for a Network Watcher operation to finish
+const DefaultNetworkWatcherTimeout = 5 * time.Minute
+
+// NetworkWatcher describes the Network Watcher of a region
+type NetworkWatcher struct {
+	ID                string
+	Name              string
+	ResourceGroup     string
+	Location          string
+	ProvisioningState string
+}
+
+// IPFlowVerifyResult is the outcome of a Network Watcher IP flow verify
+type IPFlowVerifyResult struct {
+	// Access is Allow or Deny
+	Access string
+
+	// RuleName is the name of the security rule that allowed or denied the flow
+	RuleName string
+}
+
+// Allowed returns true when the flow was allowed
+func (result IPFlowVerifyResult) Allowed() bool {
+	return result.Access == string(network.Allow)
+}
+
+// NextHop describes where the platform routes traffic to a destination
+type NextHop struct {
+	// NextHopType is e.g. Internet, VirtualAppliance, VirtualNetworkGateway, VnetLocal, HyperNetGateway or None
+	NextHopType      string
+	NextHopIPAddress string
+
+	// RouteTableID is the ID of the route table holding the route used, or "System Route" for platform routes
+	RouteTableID string
+}
+
+// EffectiveSecurityGroup holds the security rules applied to a NIC by one NSG, either associated to the NIC or to its subnet
+type EffectiveSecurityGroup struct {
+	NetworkSecurityGroupID string
+
+	// SubnetID or NetworkInterfaceID is set depending on where the NSG is associated
+	SubnetID           string
+	NetworkInterfaceID string
+
+	Rules []EffectiveSecurityRule
+}
+
+// EffectiveSecurityRule is a security rule as applied to a NIC, with service tags expanded into address prefixes
+type EffectiveSecurityRule struct {
+	SecurityRule
+
+	ExpandedSourceAddressPrefixes      []string
+	ExpandedDestinationAddressPrefixes []string
+}
+
+// EffectiveRoute is a route as applied to a NIC
+type EffectiveRoute struct {
+	Name string
+
+	// Source is Default, User, VirtualNetworkGateway or Unknown
+	Source string
+
+	// State is Active or Invalid
+	State string
+
+	AddressPrefixes            []string
+	NextHopType                string
+	NextHopIPAddresses         []string
+	DisableBgpRoutePropagation bool
+}
+
+// ConnectivityResult is the outcome of a Network Watcher connectivity check
+type ConnectivityResult struct {
+	// Status is Connected, Disconnected, Degraded or Unknown
+	Status string
+
+	AvgLatencyInMs int32
+	MinLatencyInMs int32
+	MaxLatencyInMs int32
+	ProbesSent     int32
+	ProbesFailed   int32
+	Hops           []ConnectivityHop
+}
+
+// Connected returns true when the destination was reached
+func (result ConnectivityResult) Connected() bool {
+	return result.Status == string(network.ConnectionStatusConnected)
+}
+
+// ConnectivityHop is a single hop on the path of a connectivity check
+type ConnectivityHop struct {
+	Type       string
+	ID         string
+	Address    string
+	ResourceID string
+	NextHopIDs []string
+
+	// Issues describe problems found at this hop, e.g. "Error NetworkSecurityRule"
+	Issues []string
+}
+
+// GetNetworkWatchersClient is a helper function that will setup an Azure Network Watcher client on your behalf
+func GetNetworkWatchersClient(subscriptionID string) (*network.WatchersClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Network Watcher client
+	watcherClient := network.NewWatchersClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	watcherClient.Authorizer = *authorizer
+
+	return &watcherClient, nil
+}
+
+// GetNetworkWatcherForRegion gets the Network Watcher of the given region
+func GetNetworkWatcherForRegion(t *testing.T, region string, subscriptionID string) NetworkWatcher {
+	watcher, err := GetNetworkWatcherForRegionE(t, region, subscriptionID)
+	require.NoError(t, err)
+
+	return watcher
+}
+
+// GetNetworkWatcherForRegionE gets the Network Watcher of the given region
+func GetNetworkWatcherForRegionE(t *testing.T, region string, subscriptionID string) (NetworkWatcher, error) {
+	// Create a Network Watcher client
+	watcherClient, err := GetNetworkWatchersClient(subscriptionID)
+	if err != nil {
+		return NetworkWatcher{}, err
+	}
+
+	// There is a single Network Watcher per region, usually in the NetworkWatcherRG resource group
+	watchers, err := watcherClient.ListAll(context.Background())
+	if err != nil {
+		return NetworkWatcher{}, err
+	}
+
+	if watchers.Value != nil {
+		for _, watcher := range *watchers.Value {
+			if watcher.Location != nil && normalizeRegionName(*watcher.Location) == normalizeRegionName(region) {
+				return newNetworkWatcher(watcher)
+			}
+		}
+	}
+
+	return NetworkWatcher{}, NetworkWatcherNotFound{Region: region}
+}
+
+// VerifyIPFlowOfVirtualMachine asks Network Watcher whether the given TCP or UDP flow is allowed to or from the given
+// Virtual Machine. The flow addresses must be IP addresses; the VM side address and NIC are filled in the same way as
+// for EvaluateEffectiveSecurityOfVirtualMachine.
+func VerifyIPFlowOfVirtualMachine(t *testing.T, resGroupName string, vmName string, flow NetworkFlow, subscriptionID string) IPFlowVerifyResult {
+	result, err := VerifyIPFlowOfVirtualMachineE(t, resGroupName, vmName, flow, subscriptionID)
+	require.NoError(t, err)
+
+	return result
+}
+
+// VerifyIPFlowOfVirtualMachineE asks Network Watcher whether the given TCP or UDP flow is allowed to or from the given
+// Virtual Machine. The flow addresses must be IPv4 addresses, otherwise IPAddressNotValid is returned; the VM side
+// address and NIC are filled in the same way as for EvaluateEffectiveSecurityOfVirtualMachineE.
+func VerifyIPFlowOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, flow NetworkFlow, subscriptionID string) (IPFlowVerifyResult, error) {
+	// IP flow verify only accepts IPv4 addresses, not service tags such as Internet. An empty VM side address is filled
+	// in from the NIC below.
+	for _, address := range []string{flow.SourceAddress, flow.DestinationAddress} {
+		if address == "" {
+			continue
+		}
+		if ip := net.ParseIP(address); ip == nil || ip.To4() == nil {
+			return IPFlowVerifyResult{}, IPAddressNotValid{Address: address}
+		}
+	}
+
+	vm, watcher, err := getVirtualMachineAndNetworkWatcherE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return IPFlowVerifyResult{}, err
+	}
+
+	nics, err := GetNetworkInterfacesOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return IPFlowVerifyResult{}, err
+	}
+
+	nic, ipConfig, found := selectNetworkInterfaceForFlow(nics, flow)
+	if !found {
+		if vmAddress := getVirtualMachineSideAddress(flow); vmAddress != "" {
+			return IPFlowVerifyResult{}, VMNetworkInterfaceAddressNotFound{VMName: vmName, Address: vmAddress}
+		}
+		return IPFlowVerifyResult{}, VMNetworkInterfaceNotFound{VMName: vmName}
+	}
+	flow = completeFlowForNetworkInterface(flow, ipConfig)
+
+	if err := validateNetworkFlow(flow); err != nil {
+		return IPFlowVerifyResult{}, err
+	}
+
+	// Create a Network Watcher client
+	watcherClient, err := GetNetworkWatchersClient(subscriptionID)
+	if err != nil {
+		return IPFlowVerifyResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkWatcherTimeout)
+	defer cancel()
+
+	logger.Logf(t, "Verifying IP flow %s on Virtual Machine %s", flow, vmName)
+
+	future, err := watcherClient.VerifyIPFlow(ctx, watcher.ResourceGroup, watcher.Name, newVerificationIPFlowParameters(*vm.ID, nic.ID, flow))
+	if err != nil {
+		return IPFlowVerifyResult{}, err
+	}
+	if err := waitForNetworkOperation(ctx, &future, watcherClient.Client, "IP flow verify", DefaultNetworkWatcherTimeout); err != nil {
+		return IPFlowVerifyResult{}, err
+	}
+
+	out, err := future.Result(*watcherClient)
+	if err != nil {
+		return IPFlowVerifyResult{}, err
+	}
+
+	result := IPFlowVerifyResult{Access: string(out.Access)}
+	if out.RuleName != nil {
+		result.RuleName = *out.RuleName
+	}
+
+	return result, nil
+}
+
+// GetNextHopOfVirtualMachine asks Network Watcher which next hop traffic from the primary IP of the given Virtual
+// Machine to the destination IP address takes
+func GetNextHopOfVirtualMachine(t *testing.T, resGroupName string, vmName string, destinationIP string, subscriptionID string) NextHop {
+	nextHop, err := GetNextHopOfVirtualMachineE(t, resGroupName, vmName, destinationIP, subscriptionID)
+	require.NoError(t, err)
+
+	return nextHop
+}
+
+// GetNextHopOfVirtualMachineE asks Network Watcher which next hop traffic from the primary IP of the given Virtual
+// Machine to the destination IP address takes
+func GetNextHopOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, destinationIP string, subscriptionID string) (NextHop, error) {
+	vm, watcher, err := getVirtualMachineAndNetworkWatcherE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return NextHop{}, err
+	}
+
+	nics, err := GetNetworkInterfacesOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return NextHop{}, err
+	}
+
+	nic, ipConfig, found := selectNetworkInterfaceForFlow(nics, NetworkFlow{Direction: string(network.Outbound)})
+	if !found {
+		return NextHop{}, VMNetworkInterfaceNotFound{VMName: vmName}
+	}
+
+	// Create a Network Watcher client
+	watcherClient, err := GetNetworkWatchersClient(subscriptionID)
+	if err != nil {
+		return NextHop{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkWatcherTimeout)
+	defer cancel()
+
+	parameters := network.NextHopParameters{
+		TargetResourceID:     vm.ID,
+		TargetNicResourceID:  &nic.ID,
+		SourceIPAddress:      &ipConfig.PrivateIPAddress,
+		DestinationIPAddress: &destinationIP,
+	}
+
+	future, err := watcherClient.GetNextHop(ctx, watcher.ResourceGroup, watcher.Name, parameters)
+	if err != nil {
+		return NextHop{}, err
+	}
+	if err := waitForNetworkOperation(ctx, &future, watcherClient.Client, "Next hop", DefaultNetworkWatcherTimeout); err != nil {
+		return NextHop{}, err
+	}
+
+	out, err := future.Result(*watcherClient)
+	if err != nil {
+		return NextHop{}, err
+	}
+
+	nextHop := NextHop{NextHopType: string(out.NextHopType)}
+	if out.NextHopIPAddress != nil {
+		nextHop.NextHopIPAddress = *out.NextHopIPAddress
+	}
+	if out.RouteTableID != nil {
+		nextHop.RouteTableID = *out.RouteTableID
+	}
+
+	return nextHop, nil
+}
+
+// GetEffectiveSecurityRulesOfNetworkInterface gets the security rules applied to the given NIC by the NSGs of the NIC
+// and its subnet. The NIC must be attached to a running Virtual Machine.
+func GetEffectiveSecurityRulesOfNetworkInterface(t *testing.T, resGroupName string, nicName string, subscriptionID string) []EffectiveSecurityGroup {
+	groups, err := GetEffectiveSecurityRulesOfNetworkInterfaceE(t, resGroupName, nicName, subscriptionID)
+	require.NoError(t, err)
+
+	return groups
+}
+
+// GetEffectiveSecurityRulesOfNetworkInterfaceE gets the security rules applied to the given NIC by the NSGs of the NIC
+// and its subnet. The NIC must be attached to a running Virtual Machine.
+func GetEffectiveSecurityRulesOfNetworkInterfaceE(t *testing.T, resGroupName string, nicName string, subscriptionID string) ([]EffectiveSecurityGroup, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a NIC client
+	nicClient, err := GetNetworkInterfacesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkWatcherTimeout)
+	defer cancel()
+
+	future, err := nicClient.ListEffectiveNetworkSecurityGroups(ctx, resGroupName, nicName)
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForNetworkOperation(ctx, &future, nicClient.Client, "Listing effective security rules", DefaultNetworkWatcherTimeout); err != nil {
+		return nil, err
+	}
+
+	out, err := future.Result(*nicClient)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []EffectiveSecurityGroup{}
+	if out.Value == nil {
+		return groups, nil
+	}
+
+	for _, group := range *out.Value {
+		groups = append(groups, newEffectiveSecurityGroup(group))
+	}
+
+	return groups, nil
+}
+
+// GetEffectiveRoutesOfNetworkInterface gets the routes applied to the given NIC from system routes, route tables and
+// BGP. The NIC must be attached to a running Virtual Machine.
+func GetEffectiveRoutesOfNetworkInterface(t *testing.T, resGroupName string, nicName string, subscriptionID string) []EffectiveRoute {
+	routes, err := GetEffectiveRoutesOfNetworkInterfaceE(t, resGroupName, nicName, subscriptionID)
+	require.NoError(t, err)
+
+	return routes
+}
+
+// GetEffectiveRoutesOfNetworkInterfaceE gets the routes applied to the given NIC from system routes, route tables and
+// BGP. The NIC must be attached to a running Virtual Machine.
+func GetEffectiveRoutesOfNetworkInterfaceE(t *testing.T, resGroupName string, nicName string, subscriptionID string) ([]EffectiveRoute, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a NIC client
+	nicClient, err := GetNetworkInterfacesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkWatcherTimeout)
+	defer cancel()
+
+	future, err := nicClient.GetEffectiveRouteTable(ctx, resGroupName, nicName)
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForNetworkOperation(ctx, &future, nicClient.Client, "Getting effective routes", DefaultNetworkWatcherTimeout); err != nil {
+		return nil, err
+	}
+
+	out, err := future.Result(*nicClient)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []EffectiveRoute{}
+	if out.Value == nil {
+		return routes, nil
+	}
+
+	for _, route := range *out.Value {
+		routes = append(routes, newEffectiveRoute(route))
+	}
+
+	return routes, nil
+}
+
+// CheckConnectivityFromVirtualMachine asks Network Watcher to open a TCP connection from the given Virtual Machine to
+// the destination address and port. The Virtual Machine needs the Network Watcher agent extension installed.
+func CheckConnectivityFromVirtualMachine(t *testing.T, resGroupName string, vmName string, destinationAddress string, destinationPort int32, subscriptionID string) ConnectivityResult {
+	result, err := CheckConnectivityFromVirtualMachineE(t, resGroupName, vmName, destinationAddress, destinationPort, subscriptionID)
+	require.NoError(t, err)
+
+	return result
+}
+
+// CheckConnectivityFromVirtualMachineE asks Network Watcher to open a TCP connection from the given Virtual Machine to
+// the destination address and port. The Virtual Machine needs the Network Watcher agent extension installed.
+func CheckConnectivityFromVirtualMachineE(t *testing.T, resGroupName string, vmName string, destinationAddress string, destinationPort int32, subscriptionID string) (ConnectivityResult, error) {
+	vm, watcher, err := getVirtualMachineAndNetworkWatcherE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return ConnectivityResult{}, err
+	}
+
+	// Create a Network Watcher client
+	watcherClient, err := GetNetworkWatchersClient(subscriptionID)
+	if err != nil {
+		return ConnectivityResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkWatcherTimeout)
+	defer cancel()
+
+	parameters := network.ConnectivityParameters{
+		Source:      &network.ConnectivitySource{ResourceID: vm.ID},
+		Destination: &network.ConnectivityDestination{Address: &destinationAddress, Port: &destinationPort},
+		Protocol:    network.ProtocolTCP,
+	}
+
+	logger.Logf(t, "Checking connectivity from Virtual Machine %s to %s:%d", vmName, destinationAddress, destinationPort)
+
+	future, err := watcherClient.CheckConnectivity(ctx, watcher.ResourceGroup, watcher.Name, parameters)
+	if err != nil {
+		return ConnectivityResult{}, err
+	}
+	if err := waitForNetworkOperation(ctx, &future, watcherClient.Client, "Connectivity check", DefaultNetworkWatcherTimeout); err != nil {
+		return ConnectivityResult{}, err
+	}
+
+	out, err := future.Result(*watcherClient)
+	if err != nil {
+		return ConnectivityResult{}, err
+	}
+
+	return newConnectivityResult(out), nil
+}
+
+// AssertVirtualMachineCanConnect checks that Network Watcher can open a TCP connection from the given Virtual Machine
+// to the destination address and port
+func AssertVirtualMachineCanConnect(t *testing.T, resGroupName string, vmName string, destinationAddress string, destinationPort int32, subscriptionID string) {
+	result := CheckConnectivityFromVirtualMachine(t, resGroupName, vmName, destinationAddress, destinationPort, subscriptionID)
+
+	if !assert.True(t, result.Connected(), "Check Virtual Machine %s can connect to %s:%d, got %s", vmName, destinationAddress, destinationPort, result.Status) {
+		for _, hop := range result.Hops {
+			if len(hop.Issues) > 0 {
+				logger.Logf(t, "Hop %s (%s) reported issues: %s", hop.Address, hop.Type, strings.Join(hop.Issues, ", "))
+			}
+		}
+	}
+}
+
+// getVirtualMachineAndNetworkWatcherE looks up the given Virtual Machine and the Network Watcher of its region
+func getVirtualMachineAndNetworkWatcherE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (compute.VirtualMachine, NetworkWatcher, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return vm, NetworkWatcher{}, err
+	}
+
+	// Network Watcher operations target the VM by its ID
+	if vm.ID == nil {
+		return vm, NetworkWatcher{}, ResourceIDNotValid{ID: ""}
+	}
+	if vm.Location == nil {
+		return vm, NetworkWatcher{}, VMLocationNotFound{VMName: vmName}
+	}
+
+	watcher, err := GetNetworkWatcherForRegionE(t, *vm.Location, subscriptionID)
+	if err != nil {
+		return vm, NetworkWatcher{}, err
+	}
+
+	return vm, watcher, nil
+}
+
+// waitForNetworkOperation polls a long-running network operation until it finishes or the context deadline passes. The
+// timeout is the one the context was created with and is only used to report the deadline.
+func waitForNetworkOperation(ctx context.Context, future interface {
+	WaitForCompletionRef(context.Context, autorest.Client) error
+}, client autorest.Client, operation string, timeout time.Duration) error {
+	if err := future.WaitForCompletionRef(ctx, client); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return NetworkOperationTimedOut{Operation: operation, Timeout: timeout}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// newVerificationIPFlowParameters converts a flow into IP flow verify parameters, where the local side is the VM
+func newVerificationIPFlowParameters(vmID string, nicID string, flow NetworkFlow) network.VerificationIPFlowParameters {
+	parameters := network.VerificationIPFlowParameters{
+		TargetResourceID: &vmID,
+		Protocol:         network.IPFlowProtocol(strings.ToUpper(flow.Protocol)),
+	}
+	if nicID != "" {
+		parameters.TargetNicResourceID = &nicID
+	}
+
+	localAddress, localPort := flow.DestinationAddress, formatFlowPort(flow.DestinationPort)
+	remoteAddress, remotePort := flow.SourceAddress, formatFlowPort(flow.SourcePort)
+	parameters.Direction = network.Inbound
+
+	if strings.EqualFold(flow.Direction, string(network.Outbound)) {
+		localAddress, localPort = flow.SourceAddress, formatFlowPort(flow.SourcePort)
+		remoteAddress, remotePort = flow.DestinationAddress, formatFlowPort(flow.DestinationPort)
+		parameters.Direction = network.Outbound
+	}
+
+	parameters.LocalIPAddress = &localAddress
+	parameters.LocalPort = &localPort
+	parameters.RemoteIPAddress = &remoteAddress
+	parameters.RemotePort = &remotePort
+
+	return parameters
+}
+
+// newNetworkWatcher converts a Network Watcher returned by the API into a NetworkWatcher
+func newNetworkWatcher(watcher network.Watcher) (NetworkWatcher, error) {
+	result := NetworkWatcher{}
+
+	if watcher.ID == nil {
+		return result, ResourceIDNotValid{ID: ""}
+	}
+	id, err := parseAzureResourceID(*watcher.ID)
+	if err != nil {
+		return result, err
+	}
+
+	result.ID = *watcher.ID
+	result.Name = id.Name
+	result.ResourceGroup = id.ResourceGroup
+
+	if watcher.Location != nil {
+		result.Location = *watcher.Location
+	}
+	if watcher.WatcherPropertiesFormat != nil {
+		result.ProvisioningState = string(watcher.ProvisioningState)
+	}
+
+	return result, nil
+}
+
+// newEffectiveSecurityGroup converts an effective NSG returned by the API into an EffectiveSecurityGroup
+func newEffectiveSecurityGroup(group network.EffectiveNetworkSecurityGroup) EffectiveSecurityGroup {
+	result := EffectiveSecurityGroup{Rules: []EffectiveSecurityRule{}}
+
+	if group.NetworkSecurityGroup != nil && group.NetworkSecurityGroup.ID != nil {
+		result.NetworkSecurityGroupID = *group.NetworkSecurityGroup.ID
+	}
+	if association := group.Association; association != nil {
+		if association.Subnet != nil && association.Subnet.ID != nil {
+			result.SubnetID = *association.Subnet.ID
+		}
+		if association.NetworkInterface != nil && association.NetworkInterface.ID != nil {
+			result.NetworkInterfaceID = *association.NetworkInterface.ID
+		}
+	}
+
+	if group.EffectiveSecurityRules == nil {
+		return result
+	}
+
+	for _, rule := range *group.EffectiveSecurityRules {
+		effectiveRule := EffectiveSecurityRule{
+			SecurityRule: SecurityRule{
+				Direction:                  string(rule.Direction),
+				Access:                     string(rule.Access),
+				Protocol:                   string(rule.Protocol),
+				SourceAddressPrefixes:      mergeRuleValues(rule.SourceAddressPrefix, rule.SourceAddressPrefixes),
+				SourcePortRanges:           mergeRuleValues(rule.SourcePortRange, rule.SourcePortRanges),
+				DestinationAddressPrefixes: mergeRuleValues(rule.DestinationAddressPrefix, rule.DestinationAddressPrefixes),
+				DestinationPortRanges:      mergeRuleValues(rule.DestinationPortRange, rule.DestinationPortRanges),
+			},
+			ExpandedSourceAddressPrefixes:      getStringValues(rule.ExpandedSourceAddressPrefix),
+			ExpandedDestinationAddressPrefixes: getStringValues(rule.ExpandedDestinationAddressPrefix),
+		}
+
+		// Effective rules use All rather than * for any protocol
+		if rule.Protocol == network.EffectiveSecurityRuleProtocolAll {
+			effectiveRule.Protocol = string(network.SecurityRuleProtocolAsterisk)
+		}
+		if rule.Name != nil {
+			effectiveRule.Name = *rule.Name
+		}
+		if rule.Priority != nil {
+			effectiveRule.Priority = *rule.Priority
+		}
+
+		result.Rules = append(result.Rules, effectiveRule)
+	}
+
+	return result
+}
+
+// newEffectiveRoute converts an effective route returned by the API into an EffectiveRoute
+func newEffectiveRoute(route network.EffectiveRoute) EffectiveRoute {
+	result := EffectiveRoute{
+		Source:             string(route.Source),
+		State:              string(route.State),
+		NextHopType:        string(route.NextHopType),
+		AddressPrefixes:    getStringValues(route.AddressPrefix),
+		NextHopIPAddresses: getStringValues(route.NextHopIPAddress),
+	}
+
+	if route.Name != nil {
+		result.Name = *route.Name
+	}
+	if route.DisableBgpRoutePropagation != nil {
+		result.DisableBgpRoutePropagation = *route.DisableBgpRoutePropagation
+	}
+
+	return result
+}
+
+// newConnectivityResult converts a connectivity check result returned by the API into a ConnectivityResult
+func newConnectivityResult(info network.ConnectivityInformation) ConnectivityResult {
+	result := ConnectivityResult{Status: string(info.ConnectionStatus), Hops: []ConnectivityHop{}}
+
+	if info.AvgLatencyInMs != nil {
+		result.AvgLatencyInMs = *info.AvgLatencyInMs
+	}
+	if info.MinLatencyInMs != nil {
+		result.MinLatencyInMs = *info.MinLatencyInMs
+	}
+	if info.MaxLatencyInMs != nil {
+		result.MaxLatencyInMs = *info.MaxLatencyInMs
+	}
+	if info.ProbesSent != nil {
+		result.ProbesSent = *info.ProbesSent
+	}
+	if info.ProbesFailed != nil {
+		result.ProbesFailed = *info.ProbesFailed
+	}
+
+	if info.Hops == nil {
+		return result
+	}
+
+	for _, hop := range *info.Hops {
+		connectivityHop := ConnectivityHop{NextHopIDs: getStringValues(hop.NextHopIds), Issues: []string{}}
+
+		if hop.Type != nil {
+			connectivityHop.Type = *hop.Type
+		}
+		if hop.ID != nil {
+			connectivityHop.ID = *hop.ID
+		}
+		if hop.Address != nil {
+			connectivityHop.Address = *hop.Address
+		}
+		if hop.ResourceID != nil {
+			connectivityHop.ResourceID = *hop.ResourceID
+		}
+		if hop.Issues != nil {
+			for _, issue := range *hop.Issues {
+				connectivityHop.Issues = append(connectivityHop.Issues, string(issue.Severity)+" "+string(issue.Type))
+			}
+		}
+
+		result.Hops = append(result.Hops, connectivityHop)
+	}
+
+	return result
+}
@@ -0,0 +1,109 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVerificationIPFlowParameters(t *testing.T) {
+	t.Parallel()
+
+	inbound := newVerificationIPFlowParameters("vm-id", "nic-id", NetworkFlow{
+		Direction: "Inbound", Protocol: "Tcp", SourceAddress: "203.0.113.10", DestinationAddress: "10.0.1.4", DestinationPort: 22,
+	})
+	require.Equal(t, network.Inbound, inbound.Direction)
+	require.Equal(t, network.IPFlowProtocolTCP, inbound.Protocol)
+	require.Equal(t, "10.0.1.4", *inbound.LocalIPAddress)
+	require.Equal(t, "22", *inbound.LocalPort)
+	require.Equal(t, "203.0.113.10", *inbound.RemoteIPAddress)
+	require.Equal(t, "*", *inbound.RemotePort)
+	require.Equal(t, "nic-id", *inbound.TargetNicResourceID)
+
+	outbound := newVerificationIPFlowParameters("vm-id", "", NetworkFlow{
+		Direction: "Outbound", Protocol: "udp", SourceAddress: "10.0.1.4", DestinationAddress: "8.8.8.8", DestinationPort: 53,
+	})
+	require.Equal(t, network.Outbound, outbound.Direction)
+	require.Equal(t, network.IPFlowProtocolUDP, outbound.Protocol)
+	require.Equal(t, "10.0.1.4", *outbound.LocalIPAddress)
+	require.Equal(t, "8.8.8.8", *outbound.RemoteIPAddress)
+	require.Equal(t, "53", *outbound.RemotePort)
+	require.Nil(t, outbound.TargetNicResourceID)
+}
+
+func TestNewEffectiveSecurityGroup(t *testing.T) {
+	t.Parallel()
+
+	nsgID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/web-nsg"
+	subnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/web"
+	name := "defaultSecurityRules/AllowVnetInBound"
+	priority := int32(65000)
+	prefix := "VirtualNetwork"
+	expanded := []string{"10.0.0.0/16"}
+	anyPort := "0-65535"
+
+	group := newEffectiveSecurityGroup(network.EffectiveNetworkSecurityGroup{
+		NetworkSecurityGroup: &network.SubResource{ID: &nsgID},
+		Association:          &network.EffectiveNetworkSecurityGroupAssociation{Subnet: &network.SubResource{ID: &subnetID}},
+		EffectiveSecurityRules: &[]network.EffectiveNetworkSecurityRule{{
+			Name:                        &name,
+			Priority:                    &priority,
+			Protocol:                    network.EffectiveSecurityRuleProtocolAll,
+			Access:                      network.SecurityRuleAccessAllow,
+			Direction:                   network.SecurityRuleDirectionInbound,
+			SourceAddressPrefix:         &prefix,
+			ExpandedSourceAddressPrefix: &expanded,
+			DestinationPortRange:        &anyPort,
+		}},
+	})
+
+	require.Equal(t, nsgID, group.NetworkSecurityGroupID)
+	require.Equal(t, subnetID, group.SubnetID)
+	require.Len(t, group.Rules, 1)
+	require.Equal(t, "*", group.Rules[0].Protocol)
+	require.Equal(t, []string{"VirtualNetwork"}, group.Rules[0].SourceAddressPrefixes)
+	require.Equal(t, expanded, group.Rules[0].ExpandedSourceAddressPrefixes)
+	require.Equal(t, []string{"0-65535"}, group.Rules[0].DestinationPortRanges)
+}
+
+func TestVerifyIPFlowOfVirtualMachineERejectsServiceTags(t *testing.T) {
+	t.Parallel()
+
+	flow := NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 22}
+
+	_, err := VerifyIPFlowOfVirtualMachineE(t, "rg", "vm", flow, "sub")
+
+	require.Equal(t, IPAddressNotValid{Address: "Internet"}, err)
+}
+
+func TestNewEffectiveRoute(t *testing.T) {
+	t.Parallel()
+
+	name := "default"
+
+	route := newEffectiveRoute(network.EffectiveRoute{
+		Name:             &name,
+		Source:           network.EffectiveRouteSourceUser,
+		State:            network.Active,
+		AddressPrefix:    &[]string{"0.0.0.0/0"},
+		NextHopType:      network.RouteNextHopTypeVirtualAppliance,
+		NextHopIPAddress: &[]string{"10.0.0.4"},
+	})
+
+	require.Equal(t, EffectiveRoute{
+		Name:               "default",
+		Source:             "User",
+		State:              "Active",
+		NextHopType:        "VirtualAppliance",
+		AddressPrefixes:    []string{"0.0.0.0/0"},
+		NextHopIPAddresses: []string{"10.0.0.4"},
+	}, route)
+
+	require.Equal(t, []string{}, newEffectiveRoute(network.EffectiveRoute{}).NextHopIPAddresses)
+}
@@ -0,0 +1,314 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+// NetworkInterface describes an Azure Network Interface and its IP configurations
+type NetworkInterface struct {
+	ID         string
+	Name       string
+	Primary    bool
+	MacAddress string
+
+	// NetworkSecurityGroupID is the ID of the NSG associated directly with the NIC, or empty if there is none
+	NetworkSecurityGroupID string
+
+	// DNSServers are the DNS servers configured on the NIC, while AppliedDNSServers also include those inherited
+	// from the Virtual Network
+	DNSServers        []string
+	AppliedDNSServers []string
+
+	IPForwarding          bool
+	AcceleratedNetworking bool
+	IPConfigurations      []NetworkInterfaceIPConfiguration
+}
+
+// NetworkInterfaceIPConfiguration describes a single IP configuration of an Azure Network Interface
+type NetworkInterfaceIPConfiguration struct {
+	ID                                string
+	Name                              string
+	Primary                           bool
+	PrivateIPAddress                  string
+	PrivateIPAllocationMethod         string
+	PrivateIPAddressVersion           string
+	SubnetID                          string
+	PublicIPAddressID                 string
+	ApplicationSecurityGroupIDs       []string
+	LoadBalancerBackendAddressPoolIDs []string
+}
+
+// PrivateIPAddresses returns the private IP address of each IP configuration of the NIC
+func (nic NetworkInterface) PrivateIPAddresses() []string {
+	ips := []string{}
+	for _, ipConfig := range nic.IPConfigurations {
+		if ipConfig.PrivateIPAddress != "" {
+			ips = append(ips, ipConfig.PrivateIPAddress)
+		}
+	}
+
+	return ips
+}
+
+// GetNetworkInterface gets the details of an Azure Network Interface by Name
+func GetNetworkInterface(t *testing.T, resGroupName string, nicName string, subscriptionID string) NetworkInterface {
+	nic, err := GetNetworkInterfaceE(t, resGroupName, nicName, subscriptionID)
+	require.NoError(t, err)
+
+	return nic
+}
+
+// GetNetworkInterfaceE gets the details of an Azure Network Interface by Name
+func GetNetworkInterfaceE(t *testing.T, resGroupName string, nicName string, subscriptionID string) (NetworkInterface, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return NetworkInterface{}, err
+	}
+
+	// Create a NIC client
+	nicClient, err := GetNetworkInterfacesClient(subscriptionID)
+	if err != nil {
+		return NetworkInterface{}, err
+	}
+
+	// Get the details of the Network Interface
+	nic, err := nicClient.Get(context.Background(), resGroupName, nicName, "")
+	if err != nil {
+		return NetworkInterface{}, err
+	}
+
+	return newNetworkInterface(nic), nil
+}
+
+// GetNetworkInterfacesOfVirtualMachine gets the details of every NIC attached to the given Virtual Machine
+func GetNetworkInterfacesOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) []NetworkInterface {
+	nics, err := GetNetworkInterfacesOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return nics
+}
+
+// GetNetworkInterfacesOfVirtualMachineE gets the details of every NIC attached to the given Virtual Machine
+func GetNetworkInterfacesOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) ([]NetworkInterface, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a NIC client
+	nicClient, err := GetNetworkInterfacesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	nics := []NetworkInterface{}
+	if vm.VirtualMachineProperties == nil || vm.NetworkProfile == nil || vm.NetworkProfile.NetworkInterfaces == nil {
+		return nics, nil
+	}
+
+	// NICs may live in a different resource group from the VM, so look each one up by its ID
+	for _, nicRef := range *vm.NetworkProfile.NetworkInterfaces {
+		if nicRef.ID == nil {
+			continue
+		}
+
+		id, err := parseAzureResourceID(*nicRef.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		nic, err := nicClient.Get(context.Background(), id.ResourceGroup, id.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		nics = append(nics, newNetworkInterface(nic))
+	}
+
+	return nics, nil
+}
+
+// GetPrivateIPsOfVirtualMachine gets the private IP addresses of every NIC attached to the given Virtual Machine
+func GetPrivateIPsOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) []string {
+	ips, err := GetPrivateIPsOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return ips
+}
+
+// GetPrivateIPsOfVirtualMachineE gets the private IP addresses of every NIC attached to the given Virtual Machine
+func GetPrivateIPsOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) ([]string, error) {
+	nics, err := GetNetworkInterfacesOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	for _, nic := range nics {
+		ips = append(ips, nic.PrivateIPAddresses()...)
+	}
+
+	return ips, nil
+}
+
+// GetNetworkInterfacePublicIPs gets the public IP addresses attached to the IP configurations of the given NIC
+func GetNetworkInterfacePublicIPs(t *testing.T, resGroupName string, nicName string, subscriptionID string) []string {
+	ips, err := GetNetworkInterfacePublicIPsE(t, resGroupName, nicName, subscriptionID)
+	require.NoError(t, err)
+
+	return ips
+}
+
+// GetNetworkInterfacePublicIPsE gets the public IP addresses attached to the IP configurations of the given NIC. A
+// Dynamic public IP that is not allocated yet is left out.
+func GetNetworkInterfacePublicIPsE(t *testing.T, resGroupName string, nicName string, subscriptionID string) ([]string, error) {
+	nic, err := GetNetworkInterfaceE(t, resGroupName, nicName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicIPs, err := getPublicIPsOfNetworkInterfaceE(t, nic)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	for _, publicIP := range publicIPs {
+		if publicIP.IPAddress != "" {
+			ips = append(ips, publicIP.IPAddress)
+		}
+	}
+
+	return ips, nil
+}
+
+// getPublicIPsOfNetworkInterfaceE looks up the Public IP Address resources attached to the IP configurations of a NIC
+func getPublicIPsOfNetworkInterfaceE(t *testing.T, nic NetworkInterface) ([]PublicIPAddress, error) {
+	publicIPs := []PublicIPAddress{}
+
+	for _, ipConfig := range nic.IPConfigurations {
+		if ipConfig.PublicIPAddressID == "" {
+			continue
+		}
+
+		// Public IPs may live in a different resource group from the NIC, so look each one up by its ID
+		id, err := parseAzureResourceID(ipConfig.PublicIPAddressID)
+		if err != nil {
+			return nil, err
+		}
+
+		publicIP, err := GetPublicIPAddressE(t, id.ResourceGroup, id.Name, id.SubscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		publicIPs = append(publicIPs, publicIP)
+	}
+
+	return publicIPs, nil
+}
+
+// newNetworkInterface converts a NIC returned by the API into a NetworkInterface
+func newNetworkInterface(nic network.Interface) NetworkInterface {
+	result := NetworkInterface{
+		DNSServers:        []string{},
+		AppliedDNSServers: []string{},
+		IPConfigurations:  []NetworkInterfaceIPConfiguration{},
+	}
+
+	if nic.ID != nil {
+		result.ID = *nic.ID
+	}
+	if nic.Name != nil {
+		result.Name = *nic.Name
+	}
+
+	props := nic.InterfacePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	if props.Primary != nil {
+		result.Primary = *props.Primary
+	}
+	if props.MacAddress != nil {
+		result.MacAddress = *props.MacAddress
+	}
+	if props.NetworkSecurityGroup != nil && props.NetworkSecurityGroup.ID != nil {
+		result.NetworkSecurityGroupID = *props.NetworkSecurityGroup.ID
+	}
+	if props.EnableIPForwarding != nil {
+		result.IPForwarding = *props.EnableIPForwarding
+	}
+	if props.EnableAcceleratedNetworking != nil {
+		result.AcceleratedNetworking = *props.EnableAcceleratedNetworking
+	}
+	if props.DNSSettings != nil {
+		if props.DNSSettings.DNSServers != nil {
+			result.DNSServers = append(result.DNSServers, *props.DNSSettings.DNSServers...)
+		}
+		if props.DNSSettings.AppliedDNSServers != nil {
+			result.AppliedDNSServers = append(result.AppliedDNSServers, *props.DNSSettings.AppliedDNSServers...)
+		}
+	}
+
+	if props.IPConfigurations != nil {
+		for _, ipConfig := range *props.IPConfigurations {
+			result.IPConfigurations = append(result.IPConfigurations, newNetworkInterfaceIPConfiguration(ipConfig))
+		}
+	}
+
+	return result
+}
+
+// newNetworkInterfaceIPConfiguration converts a NIC IP configuration returned by the API into a NetworkInterfaceIPConfiguration
+func newNetworkInterfaceIPConfiguration(ipConfig network.InterfaceIPConfiguration) NetworkInterfaceIPConfiguration {
+	result := NetworkInterfaceIPConfiguration{ApplicationSecurityGroupIDs: []string{}, LoadBalancerBackendAddressPoolIDs: []string{}}
+
+	if ipConfig.ID != nil {
+		result.ID = *ipConfig.ID
+	}
+	if ipConfig.Name != nil {
+		result.Name = *ipConfig.Name
+	}
+
+	props := ipConfig.InterfaceIPConfigurationPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.PrivateIPAllocationMethod = string(props.PrivateIPAllocationMethod)
+	result.PrivateIPAddressVersion = string(props.PrivateIPAddressVersion)
+
+	if props.Primary != nil {
+		result.Primary = *props.Primary
+	}
+	if props.PrivateIPAddress != nil {
+		result.PrivateIPAddress = *props.PrivateIPAddress
+	}
+	if props.Subnet != nil && props.Subnet.ID != nil {
+		result.SubnetID = *props.Subnet.ID
+	}
+	if props.PublicIPAddress != nil && props.PublicIPAddress.ID != nil {
+		result.PublicIPAddressID = *props.PublicIPAddress.ID
+	}
+	if props.ApplicationSecurityGroups != nil {
+		for _, asg := range *props.ApplicationSecurityGroups {
+			if asg.ID != nil {
+				result.ApplicationSecurityGroupIDs = append(result.ApplicationSecurityGroupIDs, *asg.ID)
+			}
+		}
+	}
+	if props.LoadBalancerBackendAddressPools != nil {
+		for _, pool := range *props.LoadBalancerBackendAddressPools {
+			if pool.ID != nil {
+				result.LoadBalancerBackendAddressPoolIDs = append(result.LoadBalancerBackendAddressPoolIDs, *pool.ID)
+			}
+		}
+	}
+
+	return result
+}
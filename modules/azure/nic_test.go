@@ -0,0 +1,154 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNetworkInterface(t *testing.T) {
+	t.Parallel()
+
+	nicID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm-nic"
+	nicName := "vm-nic"
+	nsgID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/vm-nsg"
+	macAddress := "00-0D-3A-00-00-01"
+	primary := true
+	ipForwarding := true
+	dnsServers := []string{"10.0.0.4"}
+
+	nic := newNetworkInterface(network.Interface{
+		ID:   &nicID,
+		Name: &nicName,
+		InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+			Primary:              &primary,
+			MacAddress:           &macAddress,
+			NetworkSecurityGroup: &network.SecurityGroup{ID: &nsgID},
+			EnableIPForwarding:   &ipForwarding,
+			DNSSettings:          &network.InterfaceDNSSettings{DNSServers: &dnsServers},
+			IPConfigurations:     &[]network.InterfaceIPConfiguration{{}},
+		},
+	})
+
+	require.Equal(t, NetworkInterface{
+		ID:                     nicID,
+		Name:                   "vm-nic",
+		Primary:                true,
+		MacAddress:             macAddress,
+		NetworkSecurityGroupID: nsgID,
+		DNSServers:             []string{"10.0.0.4"},
+		AppliedDNSServers:      []string{},
+		IPForwarding:           true,
+		IPConfigurations: []NetworkInterfaceIPConfiguration{{
+			ApplicationSecurityGroupIDs:       []string{},
+			LoadBalancerBackendAddressPoolIDs: []string{},
+		}},
+	}, nic)
+}
+
+func TestNewNetworkInterfaceWithoutProperties(t *testing.T) {
+	t.Parallel()
+
+	nic := newNetworkInterface(network.Interface{})
+
+	require.Equal(t, NetworkInterface{
+		DNSServers:        []string{},
+		AppliedDNSServers: []string{},
+		IPConfigurations:  []NetworkInterfaceIPConfiguration{},
+	}, nic)
+}
+
+func TestNewNetworkInterfaceIPConfiguration(t *testing.T) {
+	t.Parallel()
+
+	ipConfigID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm-nic/ipConfigurations/ipconfig1"
+	ipConfigName := "ipconfig1"
+	privateIP := "10.0.1.4"
+	subnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/web"
+	publicIPID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/vm-pip"
+	asgID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationSecurityGroups/web-asg"
+	poolID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/lb/backendAddressPools/web-pool"
+	primary := true
+
+	ipConfig := newNetworkInterfaceIPConfiguration(network.InterfaceIPConfiguration{
+		ID:   &ipConfigID,
+		Name: &ipConfigName,
+		InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+			Primary:                         &primary,
+			PrivateIPAddress:                &privateIP,
+			PrivateIPAllocationMethod:       network.Static,
+			PrivateIPAddressVersion:         network.IPv4,
+			Subnet:                          &network.Subnet{ID: &subnetID},
+			PublicIPAddress:                 &network.PublicIPAddress{ID: &publicIPID},
+			ApplicationSecurityGroups:       &[]network.ApplicationSecurityGroup{{ID: &asgID}, {}},
+			LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{{ID: &poolID}},
+		},
+	})
+
+	require.Equal(t, NetworkInterfaceIPConfiguration{
+		ID:                                ipConfigID,
+		Name:                              "ipconfig1",
+		Primary:                           true,
+		PrivateIPAddress:                  "10.0.1.4",
+		PrivateIPAllocationMethod:         "Static",
+		PrivateIPAddressVersion:           "IPv4",
+		SubnetID:                          subnetID,
+		PublicIPAddressID:                 publicIPID,
+		ApplicationSecurityGroupIDs:       []string{asgID},
+		LoadBalancerBackendAddressPoolIDs: []string{poolID},
+	}, ipConfig)
+}
+
+func TestNetworkInterfacePrivateIPAddresses(t *testing.T) {
+	t.Parallel()
+
+	nic := NetworkInterface{IPConfigurations: []NetworkInterfaceIPConfiguration{
+		{PrivateIPAddress: "10.0.1.4"},
+		{},
+		{PrivateIPAddress: "10.0.1.5"},
+	}}
+
+	require.Equal(t, []string{"10.0.1.4", "10.0.1.5"}, nic.PrivateIPAddresses())
+}
+
+func TestGetNetworkInterfaceE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	nicName := ""
+	subID := ""
+
+	_, err := GetNetworkInterfaceE(t, rgName, nicName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetNetworkInterfacesOfVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := GetNetworkInterfacesOfVirtualMachineE(t, rgName, vmName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetNetworkInterfacePublicIPsE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	nicName := ""
+	subID := ""
+
+	_, err := GetNetworkInterfacePublicIPsE(t, rgName, nicName, subID)
+
+	require.Error(t, err)
+}
@@ -0,0 +1,556 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// azureLoadBalancerAddress is the virtual IP the AzureLoadBalancer service tag stands for, used by health probes
+const azureLoadBalancerAddress = "168.63.129.16"
+
+// privateAddressRanges are the address ranges the VirtualNetwork service tag is assumed to cover when evaluating rules
+// offline, while everything outside them is assumed to be Internet
+var privateAddressRanges = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+}
+
+// NetworkSecurityGroup holds the rules and associations of an Azure Network Security Group
+type NetworkSecurityGroup struct {
+	ID   string
+	Name string
+
+	// SecurityRules are the custom rules of the NSG
+	SecurityRules []SecurityRule
+
+	// DefaultSecurityRules are the rules Azure adds to every NSG. When empty, the standard Azure default rules are
+	// used for evaluation.
+	DefaultSecurityRules []SecurityRule
+
+	NetworkInterfaceIDs []string
+	SubnetIDs           []string
+}
+
+// SecurityRule describes a single Network Security Group rule. Single and plural address prefix and port range fields
+// returned by the API are merged into the plural fields.
+type SecurityRule struct {
+	Name      string
+	Priority  int32
+	Direction string
+	Access    string
+	Protocol  string
+
+	SourceAddressPrefixes             []string
+	SourcePortRanges                  []string
+	SourceApplicationSecurityGroupIDs []string
+
+	DestinationAddressPrefixes             []string
+	DestinationPortRanges                  []string
+	DestinationApplicationSecurityGroupIDs []string
+}
+
+// NetworkFlow describes the traffic to evaluate against Network Security Group rules
+type NetworkFlow struct {
+	// Direction is Inbound or Outbound
+	Direction string
+
+	// Protocol is Tcp, Udp, Icmp, Esp or Ah
+	Protocol string
+
+	// SourceAddress and DestinationAddress are either an IP address or a service tag such as Internet or VirtualNetwork
+	SourceAddress      string
+	DestinationAddress string
+
+	// SourcePort and DestinationPort of 0 mean any port. A Deny rule must cover every port to match, while an Allow
+	// rule for any single port is enough to let the flow through.
+	SourcePort      int
+	DestinationPort int
+
+	// SourceApplicationSecurityGroupIDs and DestinationApplicationSecurityGroupIDs are the Application Security Groups
+	// the NICs at either end of the flow belong to
+	SourceApplicationSecurityGroupIDs      []string
+	DestinationApplicationSecurityGroupIDs []string
+}
+
+// String describes the flow, e.g. "Inbound Tcp from Internet:* to 10.0.1.4:22"
+func (flow NetworkFlow) String() string {
+	return fmt.Sprintf(
+		"%s %s from %s:%s to %s:%s",
+		flow.Direction,
+		flow.Protocol,
+		flow.SourceAddress,
+		formatFlowPort(flow.SourcePort),
+		flow.DestinationAddress,
+		formatFlowPort(flow.DestinationPort),
+	)
+}
+
+// SecurityRuleVerdict is the outcome of evaluating a flow against a Network Security Group
+type SecurityRuleVerdict struct {
+	Allowed bool
+
+	// Rule is the rule that decided the verdict. It is empty when no rule matched, in which case the flow is denied.
+	Rule SecurityRule
+
+	NetworkSecurityGroupID   string
+	NetworkSecurityGroupName string
+}
+
+// String describes the verdict, e.g. "Deny by rule DenyAllInBound (priority 65500) of NSG web-nsg"
+func (verdict SecurityRuleVerdict) String() string {
+	access := string(network.SecurityRuleAccessDeny)
+	if verdict.Allowed {
+		access = string(network.SecurityRuleAccessAllow)
+	}
+
+	if verdict.Rule.Name == "" {
+		return fmt.Sprintf("%s as no rule of NSG %s matched", access, verdict.NetworkSecurityGroupName)
+	}
+
+	return fmt.Sprintf("%s by rule %s (priority %d) of NSG %s", access, verdict.Rule.Name, verdict.Rule.Priority, verdict.NetworkSecurityGroupName)
+}
+
+// GetNetworkSecurityGroup gets the rules and associations of a Network Security Group by Name
+func GetNetworkSecurityGroup(t *testing.T, resGroupName string, nsgName string, subscriptionID string) NetworkSecurityGroup {
+	nsg, err := GetNetworkSecurityGroupE(t, resGroupName, nsgName, subscriptionID)
+	require.NoError(t, err)
+
+	return nsg
+}
+
+// GetNetworkSecurityGroupE gets the rules and associations of a Network Security Group by Name
+func GetNetworkSecurityGroupE(t *testing.T, resGroupName string, nsgName string, subscriptionID string) (NetworkSecurityGroup, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return NetworkSecurityGroup{}, err
+	}
+
+	// Create a Security Group client
+	nsgClient, err := GetSecurityGroupsClient(subscriptionID)
+	if err != nil {
+		return NetworkSecurityGroup{}, err
+	}
+
+	// Get the details of the Network Security Group
+	nsg, err := nsgClient.Get(context.Background(), resGroupName, nsgName, "")
+	if err != nil {
+		return NetworkSecurityGroup{}, err
+	}
+
+	return newNetworkSecurityGroup(nsg), nil
+}
+
+// EvaluateNetworkSecurityGroup evaluates the given flow against the rules of the Network Security Group in priority
+// order, the way Azure does, and returns whether the flow is allowed together with the rule that decided it.
+//
+// Service tags in rules are matched against IP addresses as follows: VirtualNetwork covers the private address ranges,
+// Internet covers every other address and AzureLoadBalancer covers 168.63.129.16. Any other service tag only matches
+// a flow address given as that same tag.
+//
+// A flow given as a service tag or with port 0 stands for many concrete flows. A Deny rule only matches it when the
+// rule covers all of them, while an Allow rule matches as soon as it covers any of them. The verdict therefore errs
+// towards Allowed, so AssertPortClosed does not pass for a port that is open to part of the flow.
+func EvaluateNetworkSecurityGroup(nsg NetworkSecurityGroup, flow NetworkFlow) (SecurityRuleVerdict, error) {
+	verdict := SecurityRuleVerdict{NetworkSecurityGroupID: nsg.ID, NetworkSecurityGroupName: nsg.Name}
+
+	if err := validateNetworkFlow(flow); err != nil {
+		return verdict, err
+	}
+
+	defaultRules := nsg.DefaultSecurityRules
+	if len(defaultRules) == 0 {
+		defaultRules = getAzureDefaultSecurityRules()
+	}
+
+	rules := []SecurityRule{}
+	for _, rule := range append(append([]SecurityRule{}, nsg.SecurityRules...), defaultRules...) {
+		if strings.EqualFold(rule.Direction, flow.Direction) {
+			rules = append(rules, rule)
+		}
+	}
+
+	// Custom rules come first, so they win over a default rule with the same priority
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority < rules[j].Priority
+	})
+
+	for _, rule := range rules {
+		if matchesSecurityRule(rule, flow) {
+			verdict.Rule = rule
+			verdict.Allowed = strings.EqualFold(rule.Access, string(network.SecurityRuleAccessAllow))
+			return verdict, nil
+		}
+	}
+
+	return verdict, nil
+}
+
+// AssertPortOpen checks that the given flow is allowed by the Network Security Group
+func AssertPortOpen(t *testing.T, nsg NetworkSecurityGroup, flow NetworkFlow) {
+	verdict, err := EvaluateNetworkSecurityGroup(nsg, flow)
+	require.NoError(t, err)
+
+	assert.True(t, verdict.Allowed, "Expected %s to be allowed, got %s", flow, verdict)
+}
+
+// AssertPortClosed checks that the given flow is denied by the Network Security Group
+func AssertPortClosed(t *testing.T, nsg NetworkSecurityGroup, flow NetworkFlow) {
+	verdict, err := EvaluateNetworkSecurityGroup(nsg, flow)
+	require.NoError(t, err)
+
+	assert.False(t, verdict.Allowed, "Expected %s to be denied, got %s", flow, verdict)
+}
+
+// validateNetworkFlow checks the given flow has everything needed to evaluate it
+func validateNetworkFlow(flow NetworkFlow) error {
+	if !strings.EqualFold(flow.Direction, string(network.SecurityRuleDirectionInbound)) &&
+		!strings.EqualFold(flow.Direction, string(network.SecurityRuleDirectionOutbound)) {
+		return NetworkFlowNotValid{Reason: fmt.Sprintf("direction must be Inbound or Outbound, got %q", flow.Direction)}
+	}
+	if flow.Protocol == "" {
+		return NetworkFlowNotValid{Reason: "protocol is not set"}
+	}
+	if flow.SourceAddress == "" || flow.DestinationAddress == "" {
+		return NetworkFlowNotValid{Reason: "source and destination address must be set"}
+	}
+
+	return nil
+}
+
+// matchesSecurityRule checks whether the given flow falls within the protocol, addresses and ports of the rule
+func matchesSecurityRule(rule SecurityRule, flow NetworkFlow) bool {
+	if rule.Protocol != string(network.SecurityRuleProtocolAsterisk) && !strings.EqualFold(rule.Protocol, flow.Protocol) {
+		return false
+	}
+
+	// An Allow rule that covers only part of the flow still opens it, while a Deny rule has to cover all of it
+	partial := strings.EqualFold(rule.Access, string(network.SecurityRuleAccessAllow))
+
+	return matchesAddress(rule.SourceAddressPrefixes, rule.SourceApplicationSecurityGroupIDs, flow.SourceAddress, flow.SourceApplicationSecurityGroupIDs, partial) &&
+		matchesAddress(rule.DestinationAddressPrefixes, rule.DestinationApplicationSecurityGroupIDs, flow.DestinationAddress, flow.DestinationApplicationSecurityGroupIDs, partial) &&
+		matchesPortRanges(rule.SourcePortRanges, flow.SourcePort, partial) &&
+		matchesPortRanges(rule.DestinationPortRanges, flow.DestinationPort, partial)
+}
+
+// matchesAddress checks whether an address matches the address prefixes of a rule, or, if the rule refers to
+// Application Security Groups instead, whether the address belongs to one of them. With partial set, a service tag
+// address also matches a prefix that covers only part of it.
+func matchesAddress(prefixes []string, ruleASGIDs []string, address string, flowASGIDs []string, partial bool) bool {
+	if len(ruleASGIDs) > 0 {
+		for _, ruleASGID := range ruleASGIDs {
+			for _, flowASGID := range flowASGIDs {
+				if strings.EqualFold(ruleASGID, flowASGID) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, prefix := range prefixes {
+		if matchesAddressPrefix(prefix, address, partial) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesAddressPrefix checks whether an IP address or service tag matches a single rule prefix, which can be *, an IP
+// address, a CIDR range or a service tag
+func matchesAddressPrefix(prefix string, address string, partial bool) bool {
+	if prefix == "*" || strings.EqualFold(prefix, "Any") || strings.EqualFold(prefix, address) {
+		return true
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		covers, overlaps := matchServiceTagAddress(prefix, address)
+		return covers || (partial && overlaps)
+	}
+
+	if _, cidr, err := net.ParseCIDR(prefix); err == nil {
+		return cidr.Contains(ip)
+	}
+	if prefixIP := net.ParseIP(prefix); prefixIP != nil {
+		return prefixIP.Equal(ip)
+	}
+
+	switch strings.ToLower(prefix) {
+	case "virtualnetwork":
+		return isPrivateAddress(ip)
+	case "internet":
+		return !isPrivateAddress(ip)
+	case "azureloadbalancer":
+		return ip.Equal(net.ParseIP(azureLoadBalancerAddress))
+	}
+
+	return false
+}
+
+// matchServiceTagAddress compares a rule prefix with a flow address given as a service tag that differs from the
+// prefix. It reports whether an IP address or CIDR prefix covers every address the tag stands for, e.g. 0.0.0.0/0
+// covers both Internet and VirtualNetwork, and whether it overlaps the tag at all, e.g. 10.0.0.0/8 overlaps
+// VirtualNetwork and 203.0.113.0/24 overlaps Internet.
+func matchServiceTagAddress(prefix string, tag string) (covers bool, overlaps bool) {
+	if prefixIP := net.ParseIP(prefix); prefixIP != nil {
+		prefix = prefixIP.String() + "/32"
+		if prefixIP.To4() == nil {
+			prefix = prefixIP.String() + "/128"
+		}
+	}
+
+	_, prefixCIDR, err := net.ParseCIDR(prefix)
+	if err != nil {
+		// The prefix is another service tag
+		return false, false
+	}
+
+	switch strings.ToLower(tag) {
+	case "virtualnetwork":
+		covers = true
+		for _, addressRange := range privateAddressRanges {
+			_, rangeCIDR, _ := net.ParseCIDR(addressRange)
+			if ipNetContains(prefixCIDR, rangeCIDR) {
+				overlaps = true
+			} else {
+				covers = false
+				if ipNetsOverlap(prefixCIDR, rangeCIDR) {
+					overlaps = true
+				}
+			}
+		}
+		return covers, overlaps
+	case "internet":
+		// Internet is every address outside the private ranges, which only a prefix for the whole address space covers
+		ones, _ := prefixCIDR.Mask.Size()
+		for _, addressRange := range privateAddressRanges {
+			_, rangeCIDR, _ := net.ParseCIDR(addressRange)
+			if ipNetContains(rangeCIDR, prefixCIDR) {
+				return false, false
+			}
+		}
+		return ones == 0, true
+	case "azureloadbalancer":
+		covers = prefixCIDR.Contains(net.ParseIP(azureLoadBalancerAddress))
+		return covers, covers
+	}
+
+	return false, false
+}
+
+// ipNetContains checks whether the outer range contains the whole inner range
+func ipNetContains(outer *net.IPNet, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// ipNetsOverlap checks whether two ranges share any address
+func ipNetsOverlap(a *net.IPNet, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// isPrivateAddress checks whether the IP address is in one of the private address ranges
+func isPrivateAddress(ip net.IP) bool {
+	for _, addressRange := range privateAddressRanges {
+		_, cidr, _ := net.ParseCIDR(addressRange)
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesPortRanges checks whether a port falls within any of the port ranges of a rule, e.g. *, 22 or 8000-8999. Port 0
+// stands for any port, so it matches * and, with partial set, any port range.
+func matchesPortRanges(portRanges []string, port int, partial bool) bool {
+	for _, portRange := range portRanges {
+		if portRange == "*" {
+			return true
+		}
+		if port == 0 {
+			if partial {
+				return true
+			}
+			continue
+		}
+
+		bounds := strings.SplitN(portRange, "-", 2)
+		low, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			continue
+		}
+		high := low
+		if len(bounds) == 2 {
+			if high, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+				continue
+			}
+		}
+
+		if port >= low && port <= high {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatFlowPort formats a flow port for display, where 0 means any port
+func formatFlowPort(port int) string {
+	if port == 0 {
+		return "*"
+	}
+
+	return strconv.Itoa(port)
+}
+
+// getAzureDefaultSecurityRules returns the default rules Azure adds to every Network Security Group
+func getAzureDefaultSecurityRules() []SecurityRule {
+	newDefaultRule := func(name string, priority int32, direction network.SecurityRuleDirection, access network.SecurityRuleAccess, source string, destination string) SecurityRule {
+		return SecurityRule{
+			Name:                       name,
+			Priority:                   priority,
+			Direction:                  string(direction),
+			Access:                     string(access),
+			Protocol:                   string(network.SecurityRuleProtocolAsterisk),
+			SourceAddressPrefixes:      []string{source},
+			SourcePortRanges:           []string{"*"},
+			DestinationAddressPrefixes: []string{destination},
+			DestinationPortRanges:      []string{"*"},
+		}
+	}
+
+	return []SecurityRule{
+		newDefaultRule("AllowVnetInBound", 65000, network.SecurityRuleDirectionInbound, network.SecurityRuleAccessAllow, "VirtualNetwork", "VirtualNetwork"),
+		newDefaultRule("AllowAzureLoadBalancerInBound", 65001, network.SecurityRuleDirectionInbound, network.SecurityRuleAccessAllow, "AzureLoadBalancer", "*"),
+		newDefaultRule("DenyAllInBound", 65500, network.SecurityRuleDirectionInbound, network.SecurityRuleAccessDeny, "*", "*"),
+		newDefaultRule("AllowVnetOutBound", 65000, network.SecurityRuleDirectionOutbound, network.SecurityRuleAccessAllow, "VirtualNetwork", "VirtualNetwork"),
+		newDefaultRule("AllowInternetOutBound", 65001, network.SecurityRuleDirectionOutbound, network.SecurityRuleAccessAllow, "*", "Internet"),
+		newDefaultRule("DenyAllOutBound", 65500, network.SecurityRuleDirectionOutbound, network.SecurityRuleAccessDeny, "*", "*"),
+	}
+}
+
+// newNetworkSecurityGroup converts a Network Security Group returned by the API into a NetworkSecurityGroup
+func newNetworkSecurityGroup(nsg network.SecurityGroup) NetworkSecurityGroup {
+	result := NetworkSecurityGroup{
+		SecurityRules:        []SecurityRule{},
+		DefaultSecurityRules: []SecurityRule{},
+		NetworkInterfaceIDs:  []string{},
+		SubnetIDs:            []string{},
+	}
+
+	if nsg.ID != nil {
+		result.ID = *nsg.ID
+	}
+	if nsg.Name != nil {
+		result.Name = *nsg.Name
+	}
+
+	props := nsg.SecurityGroupPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	if props.SecurityRules != nil {
+		for _, rule := range *props.SecurityRules {
+			result.SecurityRules = append(result.SecurityRules, newSecurityRule(rule))
+		}
+	}
+	if props.DefaultSecurityRules != nil {
+		for _, rule := range *props.DefaultSecurityRules {
+			result.DefaultSecurityRules = append(result.DefaultSecurityRules, newSecurityRule(rule))
+		}
+	}
+	if props.NetworkInterfaces != nil {
+		for _, nic := range *props.NetworkInterfaces {
+			if nic.ID != nil {
+				result.NetworkInterfaceIDs = append(result.NetworkInterfaceIDs, *nic.ID)
+			}
+		}
+	}
+	if props.Subnets != nil {
+		for _, subnet := range *props.Subnets {
+			if subnet.ID != nil {
+				result.SubnetIDs = append(result.SubnetIDs, *subnet.ID)
+			}
+		}
+	}
+
+	return result
+}
+
+// newSecurityRule converts a security rule returned by the API into a SecurityRule
+func newSecurityRule(rule network.SecurityRule) SecurityRule {
+	result := SecurityRule{}
+
+	if rule.Name != nil {
+		result.Name = *rule.Name
+	}
+
+	props := rule.SecurityRulePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.Direction = string(props.Direction)
+	result.Access = string(props.Access)
+	result.Protocol = string(props.Protocol)
+
+	if props.Priority != nil {
+		result.Priority = *props.Priority
+	}
+
+	result.SourceAddressPrefixes = mergeRuleValues(props.SourceAddressPrefix, props.SourceAddressPrefixes)
+	result.SourcePortRanges = mergeRuleValues(props.SourcePortRange, props.SourcePortRanges)
+	result.DestinationAddressPrefixes = mergeRuleValues(props.DestinationAddressPrefix, props.DestinationAddressPrefixes)
+	result.DestinationPortRanges = mergeRuleValues(props.DestinationPortRange, props.DestinationPortRanges)
+	result.SourceApplicationSecurityGroupIDs = getApplicationSecurityGroupIDs(props.SourceApplicationSecurityGroups)
+	result.DestinationApplicationSecurityGroupIDs = getApplicationSecurityGroupIDs(props.DestinationApplicationSecurityGroups)
+
+	return result
+}
+
+// mergeRuleValues combines the single and plural variant of a security rule field, which the API uses interchangeably
+func mergeRuleValues(single *string, plural *[]string) []string {
+	values := []string{}
+
+	if single != nil && *single != "" {
+		values = append(values, *single)
+	}
+	if plural != nil {
+		values = append(values, *plural...)
+	}
+
+	return values
+}
+
+// getApplicationSecurityGroupIDs collects the IDs of the given Application Security Groups
+func getApplicationSecurityGroupIDs(asgs *[]network.ApplicationSecurityGroup) []string {
+	ids := []string{}
+
+	if asgs == nil {
+		return ids
+	}
+	for _, asg := range *asgs {
+		if asg.ID != nil {
+			ids = append(ids, *asg.ID)
+		}
+	}
+
+	return ids
+}
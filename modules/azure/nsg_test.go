@@ -0,0 +1,241 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateNetworkSecurityGroup(t *testing.T) {
+	t.Parallel()
+
+	webASG := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationSecurityGroups/web"
+
+	nsg := NetworkSecurityGroup{
+		Name: "web-nsg",
+		SecurityRules: []SecurityRule{
+			{
+				Name: "DenySSHFromInternet", Priority: 100, Direction: "Inbound", Access: "Deny", Protocol: "Tcp",
+				SourceAddressPrefixes: []string{"Internet"}, SourcePortRanges: []string{"*"},
+				DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"22"},
+			},
+			{
+				Name: "AllowSSHFromOffice", Priority: 200, Direction: "Inbound", Access: "Allow", Protocol: "Tcp",
+				SourceAddressPrefixes: []string{"203.0.113.0/24", "198.51.100.7"}, SourcePortRanges: []string{"*"},
+				DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"22"},
+			},
+			{
+				Name: "AllowWebToASG", Priority: 300, Direction: "Inbound", Access: "Allow", Protocol: "*",
+				SourceAddressPrefixes: []string{"*"}, SourcePortRanges: []string{"*"},
+				DestinationApplicationSecurityGroupIDs: []string{webASG}, DestinationPortRanges: []string{"80", "8000-8999"},
+			},
+			{
+				Name: "AllowHTTPSFromAnywhere", Priority: 400, Direction: "Inbound", Access: "Allow", Protocol: "Tcp",
+				SourceAddressPrefixes: []string{"0.0.0.0/0"}, SourcePortRanges: []string{"*"},
+				DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"443"},
+			},
+			{
+				Name: "DenyRDPFromPrivateRange", Priority: 500, Direction: "Inbound", Access: "Deny", Protocol: "Tcp",
+				SourceAddressPrefixes: []string{"10.0.0.0/8"}, SourcePortRanges: []string{"*"},
+				DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"3389"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		flow        NetworkFlow
+		wantRule    string
+		wantAllowed bool
+	}{
+		{
+			name:        "SSHFromInternetTag",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 22},
+			wantRule:    "DenySSHFromInternet",
+			wantAllowed: false,
+		},
+		{
+			// The office range is public, so the Internet tag of the higher priority deny rule covers it too
+			name:        "SSHFromOfficeIP",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "203.0.113.10", DestinationAddress: "10.0.1.4", DestinationPort: 22},
+			wantRule:    "DenySSHFromInternet",
+			wantAllowed: false,
+		},
+		{
+			name:        "WebPortRangeOnASG",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 8443, DestinationApplicationSecurityGroupIDs: []string{webASG}},
+			wantRule:    "AllowWebToASG",
+			wantAllowed: true,
+		},
+		{
+			name:        "WebPortWithoutASG",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 80},
+			wantRule:    "DenyAllInBound",
+			wantAllowed: false,
+		},
+		{
+			name:        "VnetToVnet",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Udp", SourceAddress: "10.0.2.5", DestinationAddress: "10.0.1.4", DestinationPort: 53},
+			wantRule:    "AllowVnetInBound",
+			wantAllowed: true,
+		},
+		{
+			name:        "LoadBalancerProbe",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "168.63.129.16", DestinationAddress: "10.0.1.4", DestinationPort: 80},
+			wantRule:    "AllowAzureLoadBalancerInBound",
+			wantAllowed: true,
+		},
+		{
+			name:        "OutboundToInternet",
+			flow:        NetworkFlow{Direction: "Outbound", Protocol: "Tcp", SourceAddress: "10.0.1.4", DestinationAddress: "93.184.216.34", DestinationPort: 443},
+			wantRule:    "AllowInternetOutBound",
+			wantAllowed: true,
+		},
+		{
+			name:        "HTTPSFromInternetTag",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 443},
+			wantRule:    "AllowHTTPSFromAnywhere",
+			wantAllowed: true,
+		},
+		{
+			name:        "HTTPSFromVnetTag",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "VirtualNetwork", DestinationAddress: "10.0.1.4", DestinationPort: 443},
+			wantRule:    "AllowHTTPSFromAnywhere",
+			wantAllowed: true,
+		},
+		{
+			// 10.0.0.0/8 is only part of the VirtualNetwork tag, so the deny rule does not cover the whole flow
+			name:        "RDPFromVnetTag",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "VirtualNetwork", DestinationAddress: "10.0.1.4", DestinationPort: 3389},
+			wantRule:    "AllowVnetInBound",
+			wantAllowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := EvaluateNetworkSecurityGroup(nsg, tt.flow)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRule, verdict.Rule.Name)
+			require.Equal(t, tt.wantAllowed, verdict.Allowed)
+			require.Equal(t, "web-nsg", verdict.NetworkSecurityGroupName)
+		})
+	}
+
+	AssertPortClosed(t, nsg, tests[0].flow)
+	AssertPortOpen(t, nsg, tests[2].flow)
+
+	_, err := EvaluateNetworkSecurityGroup(nsg, NetworkFlow{Direction: "Sideways", Protocol: "Tcp", SourceAddress: "*", DestinationAddress: "*"})
+	require.Error(t, err)
+}
+
+func TestEvaluateNetworkSecurityGroupPartialFlows(t *testing.T) {
+	t.Parallel()
+
+	nsg := NetworkSecurityGroup{
+		Name: "office-nsg",
+		SecurityRules: []SecurityRule{
+			{
+				Name: "DenyRDP", Priority: 100, Direction: "Inbound", Access: "Deny", Protocol: "Tcp",
+				SourceAddressPrefixes: []string{"*"}, SourcePortRanges: []string{"*"},
+				DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"3389"},
+			},
+			{
+				Name: "AllowSSHFromOffice", Priority: 200, Direction: "Inbound", Access: "Allow", Protocol: "Tcp",
+				SourceAddressPrefixes: []string{"203.0.113.0/24"}, SourcePortRanges: []string{"*"},
+				DestinationAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"22"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		flow        NetworkFlow
+		wantRule    string
+		wantAllowed bool
+	}{
+		{
+			// The office range is part of Internet, so SSH is open to some of the Internet
+			name:        "InternetTagOverlapsAllowedRange",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "Internet", DestinationAddress: "10.0.1.4", DestinationPort: 22},
+			wantRule:    "AllowSSHFromOffice",
+			wantAllowed: true,
+		},
+		{
+			name:        "VnetTagOutsideAllowedRange",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "VirtualNetwork", DestinationAddress: "10.0.1.4", DestinationPort: 22},
+			wantRule:    "AllowVnetInBound",
+			wantAllowed: true,
+		},
+		{
+			// Any port includes 22, while the deny rule only covers 3389
+			name:        "AnyPortOverlapsAllowedPort",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "203.0.113.10", DestinationAddress: "10.0.1.4"},
+			wantRule:    "AllowSSHFromOffice",
+			wantAllowed: true,
+		},
+		{
+			name:        "AnyPortFromOtherAddress",
+			flow:        NetworkFlow{Direction: "Inbound", Protocol: "Tcp", SourceAddress: "198.51.100.7", DestinationAddress: "10.0.1.4"},
+			wantRule:    "DenyAllInBound",
+			wantAllowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := EvaluateNetworkSecurityGroup(nsg, tt.flow)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRule, verdict.Rule.Name)
+			require.Equal(t, tt.wantAllowed, verdict.Allowed)
+		})
+	}
+
+	AssertPortOpen(t, nsg, tests[0].flow)
+	AssertPortClosed(t, nsg, tests[3].flow)
+}
+
+func TestNewSecurityRule(t *testing.T) {
+	t.Parallel()
+
+	name := "AllowHTTPS"
+	priority := int32(100)
+	sourcePrefix := "10.0.0.0/16"
+	destinationPorts := []string{"443", "8443"}
+	asgID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationSecurityGroups/web"
+
+	rule := network.SecurityRule{
+		Name: &name,
+		SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+			Priority:                             &priority,
+			Direction:                            network.SecurityRuleDirectionInbound,
+			Access:                               network.SecurityRuleAccessAllow,
+			Protocol:                             network.SecurityRuleProtocolTCP,
+			SourceAddressPrefix:                  &sourcePrefix,
+			DestinationPortRanges:                &destinationPorts,
+			DestinationApplicationSecurityGroups: &[]network.ApplicationSecurityGroup{{ID: &asgID}},
+		},
+	}
+
+	require.Equal(t, SecurityRule{
+		Name:                                   "AllowHTTPS",
+		Priority:                               100,
+		Direction:                              "Inbound",
+		Access:                                 "Allow",
+		Protocol:                               "Tcp",
+		SourceAddressPrefixes:                  []string{"10.0.0.0/16"},
+		SourcePortRanges:                       []string{},
+		SourceApplicationSecurityGroupIDs:      []string{},
+		DestinationAddressPrefixes:             []string{},
+		DestinationPortRanges:                  []string{"443", "8443"},
+		DestinationApplicationSecurityGroupIDs: []string{asgID},
+	}, newSecurityRule(rule))
+}
@@ -0,0 +1,532 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2021-10-01/keyvault"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2021-09-01/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PrivateEndpointConnectionApproved is the status of a private endpoint connection the target resource has approved
+const PrivateEndpointConnectionApproved = "Approved"
+
+// PrivateEndpoint describes an Azure Private Endpoint and the Private Link connection to its target resource
+type PrivateEndpoint struct {
+	ID       string
+	Name     string
+	SubnetID string
+
+	// TargetResourceID is the resource the endpoint connects to, e.g. a storage account, and GroupIDs are the
+	// sub-resources it connects to, e.g. blob or vault
+	TargetResourceID string
+	GroupIDs         []string
+
+	// ConnectionStatus is Pending, Approved, Rejected or Disconnected. RequiresManualApproval is set when the
+	// connection was requested from a target the creator had no rights to approve.
+	ConnectionStatus       string
+	RequiresManualApproval bool
+
+	NetworkInterfaceIDs []string
+	PrivateIPAddresses  []string
+}
+
+// PrivateEndpointConnection describes a private endpoint connection as seen from the target resource
+type PrivateEndpointConnection struct {
+	ID                string
+	Name              string
+	PrivateEndpointID string
+	Status            string
+	Description       string
+	ProvisioningState string
+}
+
+// GetPrivateEndpointsClient is a helper function that will setup an Azure Private Endpoint client on your behalf
+func GetPrivateEndpointsClient(subscriptionID string) (*network.PrivateEndpointsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Private Endpoint client
+	privateEndpointClient := network.NewPrivateEndpointsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	privateEndpointClient.Authorizer = *authorizer
+
+	return &privateEndpointClient, nil
+}
+
+// GetStoragePrivateEndpointConnectionsClient is a helper function that will setup an Azure Storage Account private
+// endpoint connection client on your behalf
+func GetStoragePrivateEndpointConnectionsClient(subscriptionID string) (*storage.PrivateEndpointConnectionsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Storage Account private endpoint connection client
+	connectionClient := storage.NewPrivateEndpointConnectionsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	connectionClient.Authorizer = *authorizer
+
+	return &connectionClient, nil
+}
+
+// GetKeyVaultsClient is a helper function that will setup an Azure Key Vault management client on your behalf
+func GetKeyVaultsClient(subscriptionID string) (*keyvault.VaultsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Key Vault client
+	vaultClient := keyvault.NewVaultsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	vaultClient.Authorizer = *authorizer
+
+	return &vaultClient, nil
+}
+
+// GetPrivateEndpoint gets the details of an Azure Private Endpoint by Name, including the private IPs of its NICs
+func GetPrivateEndpoint(t *testing.T, resGroupName string, privateEndpointName string, subscriptionID string) PrivateEndpoint {
+	privateEndpoint, err := GetPrivateEndpointE(t, resGroupName, privateEndpointName, subscriptionID)
+	require.NoError(t, err)
+
+	return privateEndpoint
+}
+
+// GetPrivateEndpointE gets the details of an Azure Private Endpoint by Name, including the private IPs of its NICs
+func GetPrivateEndpointE(t *testing.T, resGroupName string, privateEndpointName string, subscriptionID string) (PrivateEndpoint, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return PrivateEndpoint{}, err
+	}
+
+	// Create a Private Endpoint client
+	privateEndpointClient, err := GetPrivateEndpointsClient(subscriptionID)
+	if err != nil {
+		return PrivateEndpoint{}, err
+	}
+
+	// Get the details of the Private Endpoint
+	privateEndpoint, err := privateEndpointClient.Get(context.Background(), resGroupName, privateEndpointName, "")
+	if err != nil {
+		return PrivateEndpoint{}, err
+	}
+
+	return completePrivateEndpointE(t, newPrivateEndpoint(privateEndpoint))
+}
+
+// GetPrivateEndpoints gets the details of every Private Endpoint in the given resource group
+func GetPrivateEndpoints(t *testing.T, resGroupName string, subscriptionID string) []PrivateEndpoint {
+	privateEndpoints, err := GetPrivateEndpointsE(t, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return privateEndpoints
+}
+
+// GetPrivateEndpointsE gets the details of every Private Endpoint in the given resource group
+func GetPrivateEndpointsE(t *testing.T, resGroupName string, subscriptionID string) ([]PrivateEndpoint, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Private Endpoint client
+	privateEndpointClient, err := GetPrivateEndpointsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := privateEndpointClient.ListComplete(context.Background(), resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	privateEndpoints := []PrivateEndpoint{}
+	for iterator.NotDone() {
+		privateEndpoint, err := completePrivateEndpointE(t, newPrivateEndpoint(iterator.Value()))
+		if err != nil {
+			return nil, err
+		}
+		privateEndpoints = append(privateEndpoints, privateEndpoint)
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return privateEndpoints, nil
+}
+
+// GetPrivateEndpointsInSubnet gets the details of every Private Endpoint in the given subnet
+func GetPrivateEndpointsInSubnet(t *testing.T, resGroupName string, vNetName string, sNetName string, subscriptionID string) []PrivateEndpoint {
+	privateEndpoints, err := GetPrivateEndpointsInSubnetE(t, resGroupName, vNetName, sNetName, subscriptionID)
+	require.NoError(t, err)
+
+	return privateEndpoints
+}
+
+// GetPrivateEndpointsInSubnetE gets the details of every Private Endpoint in the given subnet
+func GetPrivateEndpointsInSubnetE(t *testing.T, resGroupName string, vNetName string, sNetName string, subscriptionID string) ([]PrivateEndpoint, error) {
+	snet, err := GetSubnetbyNameE(t, resGroupName, vNetName, sNetName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	privateEndpoints := []PrivateEndpoint{}
+	if snet.SubnetPropertiesFormat == nil || snet.PrivateEndpoints == nil {
+		return privateEndpoints, nil
+	}
+
+	for _, ref := range *snet.PrivateEndpoints {
+		if ref.ID == nil {
+			continue
+		}
+
+		// Private Endpoints may live in a different resource group from the VNet, so look each one up by its ID
+		id, err := parseAzureResourceID(*ref.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		privateEndpoint, err := GetPrivateEndpointE(t, id.ResourceGroup, id.Name, id.SubscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		privateEndpoints = append(privateEndpoints, privateEndpoint)
+	}
+
+	return privateEndpoints, nil
+}
+
+// GetStorageAccountPrivateEndpointConnections gets the private endpoint connections of the given Storage Account
+func GetStorageAccountPrivateEndpointConnections(t *testing.T, resGroupName string, storageAccountName string, subscriptionID string) []PrivateEndpointConnection {
+	connections, err := GetStorageAccountPrivateEndpointConnectionsE(t, resGroupName, storageAccountName, subscriptionID)
+	require.NoError(t, err)
+
+	return connections
+}
+
+// GetStorageAccountPrivateEndpointConnectionsE gets the private endpoint connections of the given Storage Account
+func GetStorageAccountPrivateEndpointConnectionsE(t *testing.T, resGroupName string, storageAccountName string, subscriptionID string) ([]PrivateEndpointConnection, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Storage Account private endpoint connection client
+	connectionClient, err := GetStoragePrivateEndpointConnectionsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := connectionClient.List(context.Background(), resGroupName, storageAccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := []PrivateEndpointConnection{}
+	if result.Value != nil {
+		for _, connection := range *result.Value {
+			connections = append(connections, newStoragePrivateEndpointConnection(connection))
+		}
+	}
+
+	return connections, nil
+}
+
+// GetKeyVaultPrivateEndpointConnections gets the private endpoint connections of the given Key Vault
+func GetKeyVaultPrivateEndpointConnections(t *testing.T, resGroupName string, vaultName string, subscriptionID string) []PrivateEndpointConnection {
+	connections, err := GetKeyVaultPrivateEndpointConnectionsE(t, resGroupName, vaultName, subscriptionID)
+	require.NoError(t, err)
+
+	return connections
+}
+
+// GetKeyVaultPrivateEndpointConnectionsE gets the private endpoint connections of the given Key Vault
+func GetKeyVaultPrivateEndpointConnectionsE(t *testing.T, resGroupName string, vaultName string, subscriptionID string) ([]PrivateEndpointConnection, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Key Vault client
+	vaultClient, err := GetKeyVaultsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the details of the Key Vault, which include its private endpoint connections
+	vault, err := vaultClient.Get(context.Background(), resGroupName, vaultName)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := []PrivateEndpointConnection{}
+	if vault.Properties != nil && vault.Properties.PrivateEndpointConnections != nil {
+		for _, connection := range *vault.Properties.PrivateEndpointConnections {
+			connections = append(connections, newKeyVaultPrivateEndpointConnection(connection))
+		}
+	}
+
+	return connections, nil
+}
+
+// AssertStorageAccountHasApprovedPrivateEndpointInSubnet checks that the given Storage Account has an approved private
+// endpoint connection from a Private Endpoint in the given subnet
+func AssertStorageAccountHasApprovedPrivateEndpointInSubnet(t *testing.T, resGroupName string, storageAccountName string, subnetID string, subscriptionID string) {
+	connections := GetStorageAccountPrivateEndpointConnections(t, resGroupName, storageAccountName, subscriptionID)
+
+	found, err := hasApprovedPrivateEndpointInSubnetE(t, connections, subnetID)
+	require.NoError(t, err)
+
+	assert.True(t, found, "Check Storage Account %s has an approved private endpoint in subnet %s", storageAccountName, subnetID)
+}
+
+// AssertKeyVaultHasApprovedPrivateEndpointInSubnet checks that the given Key Vault has an approved private endpoint
+// connection from a Private Endpoint in the given subnet
+func AssertKeyVaultHasApprovedPrivateEndpointInSubnet(t *testing.T, resGroupName string, vaultName string, subnetID string, subscriptionID string) {
+	connections := GetKeyVaultPrivateEndpointConnections(t, resGroupName, vaultName, subscriptionID)
+
+	found, err := hasApprovedPrivateEndpointInSubnetE(t, connections, subnetID)
+	require.NoError(t, err)
+
+	assert.True(t, found, "Check Key Vault %s has an approved private endpoint in subnet %s", vaultName, subnetID)
+}
+
+// hasApprovedPrivateEndpointInSubnetE checks whether the Private Endpoint of any approved connection is one of the
+// Private Endpoints the given subnet reports. The subnet is looked up once rather than every Private Endpoint, which may
+// live in a resource group or subscription the test cannot read.
+func hasApprovedPrivateEndpointInSubnetE(t *testing.T, connections []PrivateEndpointConnection, subnetID string) (bool, error) {
+	approvedIDs := getApprovedPrivateEndpointIDs(connections)
+	if len(approvedIDs) == 0 {
+		return false, nil
+	}
+
+	id, err := parseAzureResourceID(subnetID)
+	if err != nil {
+		return false, err
+	}
+
+	subnet, err := GetSubnetbyNameE(t, id.ResourceGroup, id.Path["virtualNetworks"], id.Name, id.SubscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	return containsPrivateEndpointID(getSubnetPrivateEndpointIDs(subnet), approvedIDs), nil
+}
+
+// getSubnetPrivateEndpointIDs returns the IDs of the Private Endpoints in a subnet
+func getSubnetPrivateEndpointIDs(subnet network.Subnet) []string {
+	ids := []string{}
+
+	if subnet.SubnetPropertiesFormat == nil || subnet.PrivateEndpoints == nil {
+		return ids
+	}
+	for _, privateEndpoint := range *subnet.PrivateEndpoints {
+		if privateEndpoint.ID != nil {
+			ids = append(ids, *privateEndpoint.ID)
+		}
+	}
+
+	return ids
+}
+
+// containsPrivateEndpointID checks whether any of the wanted Private Endpoint IDs is in the given list, ignoring case
+func containsPrivateEndpointID(ids []string, wantedIDs []string) bool {
+	for _, id := range ids {
+		for _, wantedID := range wantedIDs {
+			if strings.EqualFold(id, wantedID) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// getApprovedPrivateEndpointIDs returns the IDs of the Private Endpoints whose connections are approved
+func getApprovedPrivateEndpointIDs(connections []PrivateEndpointConnection) []string {
+	ids := []string{}
+
+	for _, connection := range connections {
+		if connection.PrivateEndpointID != "" && strings.EqualFold(connection.Status, PrivateEndpointConnectionApproved) {
+			ids = append(ids, connection.PrivateEndpointID)
+		}
+	}
+
+	return ids
+}
+
+// completePrivateEndpointE fills in the private IPs of a Private Endpoint from its NICs, which the Private Endpoint
+// only refers to by ID
+func completePrivateEndpointE(t *testing.T, privateEndpoint PrivateEndpoint) (PrivateEndpoint, error) {
+	for _, nicID := range privateEndpoint.NetworkInterfaceIDs {
+		id, err := parseAzureResourceID(nicID)
+		if err != nil {
+			return PrivateEndpoint{}, err
+		}
+
+		nic, err := GetNetworkInterfaceE(t, id.ResourceGroup, id.Name, id.SubscriptionID)
+		if err != nil {
+			return PrivateEndpoint{}, err
+		}
+		privateEndpoint.PrivateIPAddresses = append(privateEndpoint.PrivateIPAddresses, nic.PrivateIPAddresses()...)
+	}
+
+	return privateEndpoint, nil
+}
+
+// newPrivateEndpoint converts a Private Endpoint returned by the API into a PrivateEndpoint. The private IPs are
+// filled in separately by completePrivateEndpointE.
+func newPrivateEndpoint(privateEndpoint network.PrivateEndpoint) PrivateEndpoint {
+	result := PrivateEndpoint{
+		GroupIDs:            []string{},
+		NetworkInterfaceIDs: []string{},
+		PrivateIPAddresses:  []string{},
+	}
+
+	if privateEndpoint.ID != nil {
+		result.ID = *privateEndpoint.ID
+	}
+	if privateEndpoint.Name != nil {
+		result.Name = *privateEndpoint.Name
+	}
+
+	props := privateEndpoint.PrivateEndpointProperties
+	if props == nil {
+		return result
+	}
+
+	if props.Subnet != nil && props.Subnet.ID != nil {
+		result.SubnetID = *props.Subnet.ID
+	}
+	if props.NetworkInterfaces != nil {
+		for _, nic := range *props.NetworkInterfaces {
+			if nic.ID != nil {
+				result.NetworkInterfaceIDs = append(result.NetworkInterfaceIDs, *nic.ID)
+			}
+		}
+	}
+
+	// A Private Endpoint has a single connection, which is either auto-approved or waiting for manual approval
+	connections := []network.PrivateLinkServiceConnection{}
+	if props.PrivateLinkServiceConnections != nil {
+		connections = append(connections, *props.PrivateLinkServiceConnections...)
+	}
+	if props.ManualPrivateLinkServiceConnections != nil && len(*props.ManualPrivateLinkServiceConnections) > 0 {
+		connections = append(connections, *props.ManualPrivateLinkServiceConnections...)
+		result.RequiresManualApproval = len(connections) == len(*props.ManualPrivateLinkServiceConnections)
+	}
+	if len(connections) == 0 || connections[0].PrivateLinkServiceConnectionProperties == nil {
+		return result
+	}
+
+	connection := connections[0].PrivateLinkServiceConnectionProperties
+	if connection.PrivateLinkServiceID != nil {
+		result.TargetResourceID = *connection.PrivateLinkServiceID
+	}
+	if connection.GroupIds != nil {
+		result.GroupIDs = *connection.GroupIds
+	}
+	if connection.PrivateLinkServiceConnectionState != nil && connection.PrivateLinkServiceConnectionState.Status != nil {
+		result.ConnectionStatus = *connection.PrivateLinkServiceConnectionState.Status
+	}
+
+	return result
+}
+
+// newStoragePrivateEndpointConnection converts a Storage Account private endpoint connection returned by the API into
+// a PrivateEndpointConnection
+func newStoragePrivateEndpointConnection(connection storage.PrivateEndpointConnection) PrivateEndpointConnection {
+	result := PrivateEndpointConnection{}
+
+	if connection.ID != nil {
+		result.ID = *connection.ID
+	}
+	if connection.Name != nil {
+		result.Name = *connection.Name
+	}
+
+	props := connection.PrivateEndpointConnectionProperties
+	if props == nil {
+		return result
+	}
+
+	result.ProvisioningState = string(props.ProvisioningState)
+	if props.PrivateEndpoint != nil && props.PrivateEndpoint.ID != nil {
+		result.PrivateEndpointID = *props.PrivateEndpoint.ID
+	}
+	if props.PrivateLinkServiceConnectionState != nil {
+		result.Status = string(props.PrivateLinkServiceConnectionState.Status)
+		if props.PrivateLinkServiceConnectionState.Description != nil {
+			result.Description = *props.PrivateLinkServiceConnectionState.Description
+		}
+	}
+
+	return result
+}
+
+// newKeyVaultPrivateEndpointConnection converts a Key Vault private endpoint connection returned by the API into a
+// PrivateEndpointConnection
+func newKeyVaultPrivateEndpointConnection(connection keyvault.PrivateEndpointConnectionItem) PrivateEndpointConnection {
+	result := PrivateEndpointConnection{}
+
+	if connection.ID != nil {
+		result.ID = *connection.ID
+		if id, err := parseAzureResourceID(*connection.ID); err == nil {
+			result.Name = id.Name
+		}
+	}
+
+	props := connection.PrivateEndpointConnectionProperties
+	if props == nil {
+		return result
+	}
+
+	result.ProvisioningState = string(props.ProvisioningState)
+	if props.PrivateEndpoint != nil && props.PrivateEndpoint.ID != nil {
+		result.PrivateEndpointID = *props.PrivateEndpoint.ID
+	}
+	if props.PrivateLinkServiceConnectionState != nil {
+		result.Status = string(props.PrivateLinkServiceConnectionState.Status)
+		if props.PrivateLinkServiceConnectionState.Description != nil {
+			result.Description = *props.PrivateLinkServiceConnectionState.Description
+		}
+	}
+
+	return result
+}
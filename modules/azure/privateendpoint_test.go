@@ -0,0 +1,106 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2021-09-01/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPrivateEndpoint(t *testing.T) {
+	t.Parallel()
+
+	subnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/endpoints"
+	nicID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/pe-blob.nic"
+	storageID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/data"
+	groupIDs := []string{"blob"}
+	status := "Pending"
+
+	privateEndpoint := newPrivateEndpoint(network.PrivateEndpoint{
+		PrivateEndpointProperties: &network.PrivateEndpointProperties{
+			Subnet:            &network.Subnet{ID: &subnetID},
+			NetworkInterfaces: &[]network.Interface{{ID: &nicID}},
+			ManualPrivateLinkServiceConnections: &[]network.PrivateLinkServiceConnection{{
+				PrivateLinkServiceConnectionProperties: &network.PrivateLinkServiceConnectionProperties{
+					PrivateLinkServiceID:              &storageID,
+					GroupIds:                          &groupIDs,
+					PrivateLinkServiceConnectionState: &network.PrivateLinkServiceConnectionState{Status: &status},
+				},
+			}},
+		},
+	})
+
+	require.Equal(t, subnetID, privateEndpoint.SubnetID)
+	require.Equal(t, storageID, privateEndpoint.TargetResourceID)
+	require.Equal(t, []string{"blob"}, privateEndpoint.GroupIDs)
+	require.Equal(t, "Pending", privateEndpoint.ConnectionStatus)
+	require.True(t, privateEndpoint.RequiresManualApproval)
+	require.Equal(t, []string{nicID}, privateEndpoint.NetworkInterfaceIDs)
+}
+
+func TestGetApprovedPrivateEndpointIDs(t *testing.T) {
+	t.Parallel()
+
+	approvedID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/pe-approved"
+	pendingID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/pe-pending"
+
+	connections := []PrivateEndpointConnection{
+		newStoragePrivateEndpointConnection(storage.PrivateEndpointConnection{
+			PrivateEndpointConnectionProperties: &storage.PrivateEndpointConnectionProperties{
+				PrivateEndpoint:                   &storage.PrivateEndpoint{ID: &approvedID},
+				PrivateLinkServiceConnectionState: &storage.PrivateLinkServiceConnectionState{Status: storage.PrivateEndpointServiceConnectionStatusApproved},
+			},
+		}),
+		{PrivateEndpointID: pendingID, Status: "Pending"},
+	}
+
+	require.Equal(t, []string{approvedID}, getApprovedPrivateEndpointIDs(connections))
+}
+
+func TestHasApprovedPrivateEndpointInSubnetE(t *testing.T) {
+	t.Parallel()
+
+	subnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/pe"
+
+	found, err := hasApprovedPrivateEndpointInSubnetE(t, []PrivateEndpointConnection{{PrivateEndpointID: "pe-pending", Status: "Pending"}}, subnetID)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, err = hasApprovedPrivateEndpointInSubnetE(t, []PrivateEndpointConnection{{PrivateEndpointID: "pe-approved", Status: "Approved"}}, "pe")
+	require.Error(t, err)
+}
+
+func TestGetSubnetPrivateEndpointIDs(t *testing.T) {
+	t.Parallel()
+
+	subnetPEID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/pe-storage"
+	otherPEID := "/subscriptions/other/resourceGroups/other-rg/providers/Microsoft.Network/privateEndpoints/pe-other"
+
+	subnet := network.Subnet{SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+		PrivateEndpoints: &[]network.PrivateEndpoint{{ID: &subnetPEID}, {}},
+	}}
+
+	ids := getSubnetPrivateEndpointIDs(subnet)
+	require.Equal(t, []string{subnetPEID}, ids)
+	require.Equal(t, []string{}, getSubnetPrivateEndpointIDs(network.Subnet{}))
+
+	require.True(t, containsPrivateEndpointID(ids, []string{otherPEID, strings.ToUpper(subnetPEID)}))
+	require.False(t, containsPrivateEndpointID(ids, []string{otherPEID}))
+}
+
+func TestGetPrivateEndpointsE(t *testing.T) {
+	t.Parallel()
+
+	resGroupName := ""
+	subscriptionID := ""
+
+	_, err := GetPrivateEndpointsE(t, resGroupName, subscriptionID)
+	require.Error(t, err)
+}
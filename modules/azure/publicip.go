@@ -0,0 +1,208 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+// PublicIPAddress describes an Azure Public IP Address
+type PublicIPAddress struct {
+	ID   string
+	Name string
+
+	// IPAddress is empty for a Dynamic address that is not associated with a running resource
+	IPAddress        string
+	Sku              string
+	AllocationMethod string
+	IPVersion        string
+	DomainNameLabel  string
+	Fqdn             string
+	Zones            []string
+
+	IdleTimeoutInMinutes int32
+
+	// AssociatedIPConfigurationID is the IP configuration the address is attached to, e.g. of a NIC or a load balancer
+	// frontend, and AssociatedResourceID is the resource owning it. Both are empty for an unassociated address.
+	AssociatedIPConfigurationID string
+	AssociatedResourceID        string
+}
+
+// GetPublicIPAddressesClient is a helper function that will setup an Azure Public IP Address client on your behalf
+func GetPublicIPAddressesClient(subscriptionID string) (*network.PublicIPAddressesClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Public IP Address client
+	publicIPClient := network.NewPublicIPAddressesClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	publicIPClient.Authorizer = *authorizer
+
+	return &publicIPClient, nil
+}
+
+// GetPublicIPAddress gets the details of an Azure Public IP Address by Name
+func GetPublicIPAddress(t *testing.T, resGroupName string, publicIPName string, subscriptionID string) PublicIPAddress {
+	publicIP, err := GetPublicIPAddressE(t, resGroupName, publicIPName, subscriptionID)
+	require.NoError(t, err)
+
+	return publicIP
+}
+
+// GetPublicIPAddressE gets the details of an Azure Public IP Address by Name
+func GetPublicIPAddressE(t *testing.T, resGroupName string, publicIPName string, subscriptionID string) (PublicIPAddress, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return PublicIPAddress{}, err
+	}
+
+	// Create a Public IP Address client
+	publicIPClient, err := GetPublicIPAddressesClient(subscriptionID)
+	if err != nil {
+		return PublicIPAddress{}, err
+	}
+
+	// Get the details of the Public IP Address
+	publicIP, err := publicIPClient.Get(context.Background(), resGroupName, publicIPName, "")
+	if err != nil {
+		return PublicIPAddress{}, err
+	}
+
+	return newPublicIPAddress(publicIP), nil
+}
+
+// GetPublicIPAddresses gets the details of every Public IP Address in the given resource group
+func GetPublicIPAddresses(t *testing.T, resGroupName string, subscriptionID string) []PublicIPAddress {
+	publicIPs, err := GetPublicIPAddressesE(t, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return publicIPs
+}
+
+// GetPublicIPAddressesE gets the details of every Public IP Address in the given resource group
+func GetPublicIPAddressesE(t *testing.T, resGroupName string, subscriptionID string) ([]PublicIPAddress, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Public IP Address client
+	publicIPClient, err := GetPublicIPAddressesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := publicIPClient.ListComplete(context.Background(), resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	publicIPs := []PublicIPAddress{}
+	for iterator.NotDone() {
+		publicIPs = append(publicIPs, newPublicIPAddress(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return publicIPs, nil
+}
+
+// GetVMPublicIPs gets the Public IP Addresses of the given Virtual Machine in the subscription set by the
+// ARM_SUBSCRIPTION_ID environment variable. See GetPublicIPsOfVirtualMachine to target another subscription.
+func GetVMPublicIPs(t *testing.T, resGroupName string, vmName string) []PublicIPAddress {
+	return GetPublicIPsOfVirtualMachine(t, resGroupName, vmName, "")
+}
+
+// GetPublicIPsOfVirtualMachine gets the Public IP Addresses attached to the IP configurations of every NIC of the given
+// Virtual Machine
+func GetPublicIPsOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) []PublicIPAddress {
+	publicIPs, err := GetPublicIPsOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return publicIPs
+}
+
+// GetPublicIPsOfVirtualMachineE gets the Public IP Addresses attached to the IP configurations of every NIC of the given
+// Virtual Machine
+func GetPublicIPsOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) ([]PublicIPAddress, error) {
+	nics, err := GetNetworkInterfacesOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicIPs := []PublicIPAddress{}
+	for _, nic := range nics {
+		nicPublicIPs, err := getPublicIPsOfNetworkInterfaceE(t, nic)
+		if err != nil {
+			return nil, err
+		}
+		publicIPs = append(publicIPs, nicPublicIPs...)
+	}
+
+	return publicIPs, nil
+}
+
+// newPublicIPAddress converts a Public IP Address returned by the API into a PublicIPAddress
+func newPublicIPAddress(publicIP network.PublicIPAddress) PublicIPAddress {
+	result := PublicIPAddress{Zones: []string{}}
+
+	if publicIP.ID != nil {
+		result.ID = *publicIP.ID
+	}
+	if publicIP.Name != nil {
+		result.Name = *publicIP.Name
+	}
+	if publicIP.Sku != nil {
+		result.Sku = string(publicIP.Sku.Name)
+	}
+	if publicIP.Zones != nil {
+		result.Zones = *publicIP.Zones
+	}
+
+	props := publicIP.PublicIPAddressPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.AllocationMethod = string(props.PublicIPAllocationMethod)
+	result.IPVersion = string(props.PublicIPAddressVersion)
+
+	if props.IPAddress != nil {
+		result.IPAddress = *props.IPAddress
+	}
+	if props.IdleTimeoutInMinutes != nil {
+		result.IdleTimeoutInMinutes = *props.IdleTimeoutInMinutes
+	}
+	if props.DNSSettings != nil {
+		if props.DNSSettings.DomainNameLabel != nil {
+			result.DomainNameLabel = *props.DNSSettings.DomainNameLabel
+		}
+		if props.DNSSettings.Fqdn != nil {
+			result.Fqdn = *props.DNSSettings.Fqdn
+		}
+	}
+	if props.IPConfiguration != nil && props.IPConfiguration.ID != nil {
+		result.AssociatedIPConfigurationID = *props.IPConfiguration.ID
+		if id, err := parseAzureResourceID(*props.IPConfiguration.ID); err == nil {
+			result.AssociatedResourceID = id.ParentID
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPublicIPAddress(t *testing.T) {
+	t.Parallel()
+
+	id := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/vm-pip"
+	name := "vm-pip"
+	address := "20.1.2.3"
+	label := "myvm"
+	fqdn := "myvm.eastus.cloudapp.azure.com"
+	idleTimeout := int32(4)
+	nicID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm-nic"
+	ipConfigID := nicID + "/ipConfigurations/ipconfig1"
+
+	publicIP := newPublicIPAddress(network.PublicIPAddress{
+		ID:    &id,
+		Name:  &name,
+		Sku:   &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
+		Zones: &[]string{"1", "2", "3"},
+		PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+			PublicIPAllocationMethod: network.Static,
+			PublicIPAddressVersion:   network.IPv4,
+			IPAddress:                &address,
+			IdleTimeoutInMinutes:     &idleTimeout,
+			DNSSettings:              &network.PublicIPAddressDNSSettings{DomainNameLabel: &label, Fqdn: &fqdn},
+			IPConfiguration:          &network.IPConfiguration{ID: &ipConfigID},
+		},
+	})
+
+	require.Equal(t, PublicIPAddress{
+		ID:                          id,
+		Name:                        "vm-pip",
+		IPAddress:                   "20.1.2.3",
+		Sku:                         "Standard",
+		AllocationMethod:            "Static",
+		IPVersion:                   "IPv4",
+		DomainNameLabel:             "myvm",
+		Fqdn:                        fqdn,
+		Zones:                       []string{"1", "2", "3"},
+		IdleTimeoutInMinutes:        4,
+		AssociatedIPConfigurationID: ipConfigID,
+		AssociatedResourceID:        nicID,
+	}, publicIP)
+}
+
+func TestGetPublicIPsOfVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetPublicIPsOfVirtualMachineE(t, "", "", "")
+	require.Error(t, err)
+}
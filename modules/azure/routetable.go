@@ -0,0 +1,278 @@
+package azure
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+// RouteSourceUser and RouteSourceDefault tell whether a next hop was chosen by a user defined route or by one of the
+// system routes Azure creates for every subnet
+const (
+	RouteSourceUser    = "User"
+	RouteSourceDefault = "Default"
+)
+
+// RouteTable describes an Azure Route Table and the subnets it is associated with
+type RouteTable struct {
+	ID                         string
+	Name                       string
+	Routes                     []UserDefinedRoute
+	DisableBgpRoutePropagation bool
+	SubnetIDs                  []string
+}
+
+// UserDefinedRoute describes a route of a Route Table. NextHopIPAddress is only set for VirtualAppliance routes.
+type UserDefinedRoute struct {
+	ID               string
+	Name             string
+	AddressPrefix    string
+	NextHopType      string
+	NextHopIPAddress string
+}
+
+// RouteNextHop is the next hop traffic to a destination would use, and the route that selected it
+type RouteNextHop struct {
+	// RouteName is empty for system routes
+	RouteName        string
+	Source           string
+	AddressPrefix    string
+	NextHopType      string
+	NextHopIPAddress string
+}
+
+// azureSystemRoutes are the default routes Azure creates for every subnet, besides the VNet address space itself. The
+// private ranges are dropped unless a more specific route, e.g. the VNet or a peering, covers them.
+var azureSystemRoutes = []UserDefinedRoute{
+	{AddressPrefix: "0.0.0.0/0", NextHopType: string(network.RouteNextHopTypeInternet)},
+	{AddressPrefix: "10.0.0.0/8", NextHopType: string(network.RouteNextHopTypeNone)},
+	{AddressPrefix: "172.16.0.0/12", NextHopType: string(network.RouteNextHopTypeNone)},
+	{AddressPrefix: "192.168.0.0/16", NextHopType: string(network.RouteNextHopTypeNone)},
+	{AddressPrefix: "100.64.0.0/10", NextHopType: string(network.RouteNextHopTypeNone)},
+}
+
+// GetRouteTablesClient is a helper function that will setup an Azure Route Table client on your behalf
+func GetRouteTablesClient(subscriptionID string) (*network.RouteTablesClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Route Table client
+	routeTableClient := network.NewRouteTablesClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	routeTableClient.Authorizer = *authorizer
+
+	return &routeTableClient, nil
+}
+
+// GetRouteTable gets the routes and subnet associations of an Azure Route Table by Name
+func GetRouteTable(t *testing.T, resGroupName string, routeTableName string, subscriptionID string) RouteTable {
+	routeTable, err := GetRouteTableE(t, resGroupName, routeTableName, subscriptionID)
+	require.NoError(t, err)
+
+	return routeTable
+}
+
+// GetRouteTableE gets the routes and subnet associations of an Azure Route Table by Name
+func GetRouteTableE(t *testing.T, resGroupName string, routeTableName string, subscriptionID string) (RouteTable, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return RouteTable{}, err
+	}
+
+	// Create a Route Table client
+	routeTableClient, err := GetRouteTablesClient(subscriptionID)
+	if err != nil {
+		return RouteTable{}, err
+	}
+
+	// Get the details of the Route Table
+	routeTable, err := routeTableClient.Get(context.Background(), resGroupName, routeTableName, "")
+	if err != nil {
+		return RouteTable{}, err
+	}
+
+	return newRouteTable(routeTable), nil
+}
+
+// GetRouteTableOfSubnet gets the Route Table associated with the given subnet, or an empty RouteTable if there is none
+func GetRouteTableOfSubnet(t *testing.T, resGroupName string, vNetName string, sNetName string, subscriptionID string) RouteTable {
+	routeTable, err := GetRouteTableOfSubnetE(t, resGroupName, vNetName, sNetName, subscriptionID)
+	require.NoError(t, err)
+
+	return routeTable
+}
+
+// GetRouteTableOfSubnetE gets the Route Table associated with the given subnet, or an empty RouteTable if there is none
+func GetRouteTableOfSubnetE(t *testing.T, resGroupName string, vNetName string, sNetName string, subscriptionID string) (RouteTable, error) {
+	snet, err := GetSubnetbyNameE(t, resGroupName, vNetName, sNetName, subscriptionID)
+	if err != nil {
+		return RouteTable{}, err
+	}
+
+	if snet.SubnetPropertiesFormat == nil || snet.RouteTable == nil || snet.RouteTable.ID == nil {
+		return newRouteTable(network.RouteTable{}), nil
+	}
+
+	// The Route Table may live in a different resource group from the VNet, so look it up by its ID
+	id, err := parseAzureResourceID(*snet.RouteTable.ID)
+	if err != nil {
+		return RouteTable{}, err
+	}
+
+	return GetRouteTableE(t, id.ResourceGroup, id.Name, id.SubscriptionID)
+}
+
+// GetNextHopForSubnet gets the next hop traffic from the given subnet to a destination IP would use, based on the
+// Route Table of the subnet and the system routes of its VNet. See FindNextHop for how the route is chosen.
+func GetNextHopForSubnet(t *testing.T, resGroupName string, vNetName string, sNetName string, destinationIP string, subscriptionID string) RouteNextHop {
+	nextHop, err := GetNextHopForSubnetE(t, resGroupName, vNetName, sNetName, destinationIP, subscriptionID)
+	require.NoError(t, err)
+
+	return nextHop
+}
+
+// GetNextHopForSubnetE gets the next hop traffic from the given subnet to a destination IP would use, based on the
+// Route Table of the subnet and the system routes of its VNet. See FindNextHop for how the route is chosen.
+func GetNextHopForSubnetE(t *testing.T, resGroupName string, vNetName string, sNetName string, destinationIP string, subscriptionID string) (RouteNextHop, error) {
+	vnet, err := GetVnetbyNameE(t, resGroupName, vNetName, subscriptionID)
+	if err != nil {
+		return RouteNextHop{}, err
+	}
+
+	routeTable, err := GetRouteTableOfSubnetE(t, resGroupName, vNetName, sNetName, subscriptionID)
+	if err != nil {
+		return RouteNextHop{}, err
+	}
+
+	addressSpace := []string{}
+	if vnet.VirtualNetworkPropertiesFormat != nil && vnet.AddressSpace != nil && vnet.AddressSpace.AddressPrefixes != nil {
+		addressSpace = *vnet.AddressSpace.AddressPrefixes
+	}
+
+	return FindNextHop(routeTable, addressSpace, destinationIP)
+}
+
+// FindNextHop works out offline which next hop traffic to the destination IP would use from a subnet with the given
+// Route Table, in a VNet with the given address space. Like Azure, it picks the route with the longest matching prefix
+// and prefers a user defined route over a system route with the same prefix. Routes to service tags, and the routes
+// Azure adds for peerings, gateways and service endpoints, are not taken into account.
+func FindNextHop(routeTable RouteTable, vnetAddressSpace []string, destinationIP string) (RouteNextHop, error) {
+	ip := net.ParseIP(destinationIP)
+	if ip == nil || ip.To4() == nil {
+		return RouteNextHop{}, IPAddressNotValid{Address: destinationIP}
+	}
+
+	// System routes go first so that user defined routes with the same prefix length replace them
+	candidates := []RouteNextHop{}
+	for _, prefix := range vnetAddressSpace {
+		candidates = append(candidates, RouteNextHop{Source: RouteSourceDefault, AddressPrefix: prefix, NextHopType: string(network.RouteNextHopTypeVnetLocal)})
+	}
+	for _, route := range azureSystemRoutes {
+		candidates = append(candidates, RouteNextHop{Source: RouteSourceDefault, AddressPrefix: route.AddressPrefix, NextHopType: route.NextHopType})
+	}
+	for _, route := range routeTable.Routes {
+		candidates = append(candidates, RouteNextHop{
+			RouteName:        route.Name,
+			Source:           RouteSourceUser,
+			AddressPrefix:    route.AddressPrefix,
+			NextHopType:      route.NextHopType,
+			NextHopIPAddress: route.NextHopIPAddress,
+		})
+	}
+
+	best := RouteNextHop{}
+	bestLength := -1
+	for _, candidate := range candidates {
+		_, cidr, err := net.ParseCIDR(candidate.AddressPrefix)
+		if err != nil || !cidr.Contains(ip) {
+			continue
+		}
+
+		length, _ := cidr.Mask.Size()
+		if length > bestLength || (length == bestLength && candidate.Source == RouteSourceUser) {
+			best = candidate
+			bestLength = length
+		}
+	}
+
+	return best, nil
+}
+
+// newRouteTable converts a Route Table returned by the API into a RouteTable
+func newRouteTable(routeTable network.RouteTable) RouteTable {
+	result := RouteTable{
+		Routes:    []UserDefinedRoute{},
+		SubnetIDs: []string{},
+	}
+
+	if routeTable.ID != nil {
+		result.ID = *routeTable.ID
+	}
+	if routeTable.Name != nil {
+		result.Name = *routeTable.Name
+	}
+
+	props := routeTable.RouteTablePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	if props.DisableBgpRoutePropagation != nil {
+		result.DisableBgpRoutePropagation = *props.DisableBgpRoutePropagation
+	}
+	if props.Routes != nil {
+		for _, route := range *props.Routes {
+			result.Routes = append(result.Routes, newUserDefinedRoute(route))
+		}
+	}
+	if props.Subnets != nil {
+		for _, subnet := range *props.Subnets {
+			if subnet.ID != nil {
+				result.SubnetIDs = append(result.SubnetIDs, *subnet.ID)
+			}
+		}
+	}
+
+	return result
+}
+
+// newUserDefinedRoute converts a route returned by the API into a UserDefinedRoute
+func newUserDefinedRoute(route network.Route) UserDefinedRoute {
+	result := UserDefinedRoute{}
+
+	if route.ID != nil {
+		result.ID = *route.ID
+	}
+	if route.Name != nil {
+		result.Name = *route.Name
+	}
+
+	props := route.RoutePropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.NextHopType = string(props.NextHopType)
+	if props.AddressPrefix != nil {
+		result.AddressPrefix = *props.AddressPrefix
+	}
+	if props.NextHopIPAddress != nil {
+		result.NextHopIPAddress = *props.NextHopIPAddress
+	}
+
+	return result
+}
@@ -0,0 +1,66 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindNextHop(t *testing.T) {
+	t.Parallel()
+
+	vnetAddressSpace := []string{"10.1.0.0/16"}
+	routeTable := RouteTable{Routes: []UserDefinedRoute{
+		{Name: "default-to-firewall", AddressPrefix: "0.0.0.0/0", NextHopType: "VirtualAppliance", NextHopIPAddress: "10.0.0.4"},
+		{Name: "hub", AddressPrefix: "10.0.0.0/16", NextHopType: "VirtualAppliance", NextHopIPAddress: "10.0.0.4"},
+		{Name: "blackhole", AddressPrefix: "10.1.2.0/24", NextHopType: "None"},
+	}}
+
+	tests := []struct {
+		name          string
+		destinationIP string
+		want          RouteNextHop
+	}{
+		{name: "InternetThroughFirewall", destinationIP: "8.8.8.8", want: RouteNextHop{RouteName: "default-to-firewall", Source: RouteSourceUser, AddressPrefix: "0.0.0.0/0", NextHopType: "VirtualAppliance", NextHopIPAddress: "10.0.0.4"}},
+		{name: "HubThroughFirewall", destinationIP: "10.0.1.10", want: RouteNextHop{RouteName: "hub", Source: RouteSourceUser, AddressPrefix: "10.0.0.0/16", NextHopType: "VirtualAppliance", NextHopIPAddress: "10.0.0.4"}},
+		{name: "LocalVnet", destinationIP: "10.1.1.4", want: RouteNextHop{Source: RouteSourceDefault, AddressPrefix: "10.1.0.0/16", NextHopType: "VnetLocal"}},
+		{name: "MoreSpecificUserRoute", destinationIP: "10.1.2.4", want: RouteNextHop{RouteName: "blackhole", Source: RouteSourceUser, AddressPrefix: "10.1.2.0/24", NextHopType: "None"}},
+		{name: "UnroutedPrivateRange", destinationIP: "192.168.1.1", want: RouteNextHop{Source: RouteSourceDefault, AddressPrefix: "192.168.0.0/16", NextHopType: "None"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindNextHop(routeTable, vnetAddressSpace, tt.destinationIP)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFindNextHopWithoutRouteTable(t *testing.T) {
+	t.Parallel()
+
+	nextHop, err := FindNextHop(RouteTable{}, []string{"10.1.0.0/16"}, "8.8.8.8")
+	require.NoError(t, err)
+	require.Equal(t, "Internet", nextHop.NextHopType)
+
+	_, err = FindNextHop(RouteTable{}, []string{"10.1.0.0/16"}, "not-an-ip")
+	require.Error(t, err)
+}
+
+func TestGetRouteTableE(t *testing.T) {
+	t.Parallel()
+
+	resGroupName := ""
+	routeTableName := ""
+	subscriptionID := ""
+
+	_, err := GetRouteTableE(t, resGroupName, routeTableName, subscriptionID)
+	require.Error(t, err)
+}
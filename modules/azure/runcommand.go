@@ -0,0 +1,223 @@
+package azure
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// RunShellScriptCommandID is the Run Command ID used to execute a shell script on a Linux Virtual Machine
+	RunShellScriptCommandID = "RunShellScript"
+
+	// RunPowerShellScriptCommandID is the Run Command ID used to execute a PowerShell script on a Windows Virtual Machine
+	RunPowerShellScriptCommandID = "RunPowerShellScript"
+
+	// DefaultRunCommandTimeout is how long the Run Command helpers wait for a script to finish before giving up
+	DefaultRunCommandTimeout = 10 * time.Minute
+)
+
+// exitStatusRegexp matches the exit status the Linux guest agent reports when a script fails
+var exitStatusRegexp = regexp.MustCompile(`exit status=(\d+)`)
+
+// VMRunCommandResult is the parsed output of a script executed on a Virtual Machine through the Run Command API
+type VMRunCommandResult struct {
+	StdOut string
+	StdErr string
+
+	// Succeeded is true when the guest agent reported the script as succeeded
+	Succeeded bool
+
+	// ExitCode is the exit status of the script. The guest agent only reports it for failed Linux scripts,
+	// so it is 0 whenever the status does not contain one.
+	ExitCode int
+}
+
+// RunShellScriptOnVirtualMachine runs a shell script on the given Linux Virtual Machine and returns its output
+func RunShellScriptOnVirtualMachine(t *testing.T, resGroupName string, vmName string, script []string, subscriptionID string) VMRunCommandResult {
+	result, err := RunShellScriptOnVirtualMachineE(t, resGroupName, vmName, script, subscriptionID)
+	require.NoError(t, err)
+
+	return result
+}
+
+// RunShellScriptOnVirtualMachineE runs a shell script on the given Linux Virtual Machine and returns its output
+func RunShellScriptOnVirtualMachineE(t *testing.T, resGroupName string, vmName string, script []string, subscriptionID string) (VMRunCommandResult, error) {
+	return RunCommandOnVirtualMachineE(t, resGroupName, vmName, RunShellScriptCommandID, script, DefaultRunCommandTimeout, subscriptionID)
+}
+
+// RunPowerShellScriptOnVirtualMachine runs a PowerShell script on the given Windows Virtual Machine and returns its output
+func RunPowerShellScriptOnVirtualMachine(t *testing.T, resGroupName string, vmName string, script []string, subscriptionID string) VMRunCommandResult {
+	result, err := RunPowerShellScriptOnVirtualMachineE(t, resGroupName, vmName, script, subscriptionID)
+	require.NoError(t, err)
+
+	return result
+}
+
+// RunPowerShellScriptOnVirtualMachineE runs a PowerShell script on the given Windows Virtual Machine and returns its output
+func RunPowerShellScriptOnVirtualMachineE(t *testing.T, resGroupName string, vmName string, script []string, subscriptionID string) (VMRunCommandResult, error) {
+	return RunCommandOnVirtualMachineE(t, resGroupName, vmName, RunPowerShellScriptCommandID, script, DefaultRunCommandTimeout, subscriptionID)
+}
+
+// RunCommandOnVirtualMachine runs the given Run Command on a Virtual Machine, waiting up to timeout for it to finish
+func RunCommandOnVirtualMachine(t *testing.T, resGroupName string, vmName string, commandID string, script []string, timeout time.Duration, subscriptionID string) VMRunCommandResult {
+	result, err := RunCommandOnVirtualMachineE(t, resGroupName, vmName, commandID, script, timeout, subscriptionID)
+	require.NoError(t, err)
+
+	return result
+}
+
+// RunCommandOnVirtualMachineE runs the given Run Command on a Virtual Machine, waiting up to timeout for it to finish
+func RunCommandOnVirtualMachineE(t *testing.T, resGroupName string, vmName string, commandID string, script []string, timeout time.Duration, subscriptionID string) (VMRunCommandResult, error) {
+	result := VMRunCommandResult{}
+
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return result, err
+	}
+
+	// Create a VM client
+	vmClient, err := GetVirtualMachineClient(subscriptionID)
+	if err != nil {
+		return result, err
+	}
+
+	// Run Command is executed by the guest agent, so make sure it is ready before sending the script
+	instanceView, err := vmClient.InstanceView(context.Background(), resGroupName, vmName)
+	if err != nil {
+		return result, err
+	}
+	if err := checkVMAgentReady(vmName, instanceView.VMAgent); err != nil {
+		return result, err
+	}
+
+	logger.Logf(t, "Running %s on Virtual Machine %s", commandID, vmName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	input := compute.RunCommandInput{
+		CommandID: &commandID,
+		Script:    &script,
+	}
+
+	// Start the command and wait on the long-running operation
+	future, err := vmClient.RunCommand(ctx, resGroupName, vmName, input)
+	if err != nil {
+		return result, err
+	}
+	if err := future.WaitForCompletionRef(ctx, vmClient.Client); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return result, RunCommandTimedOut{VMName: vmName, CommandID: commandID, Timeout: timeout}
+		}
+		return result, err
+	}
+
+	out, err := future.Result(*vmClient)
+	if err != nil {
+		return result, err
+	}
+
+	return parseRunCommandResult(out), nil
+}
+
+// checkVMAgentReady returns a VMAgentNotReady error unless the guest agent reports a Ready status
+func checkVMAgentReady(vmName string, agent *compute.VirtualMachineAgentInstanceView) error {
+	if agent == nil || agent.Statuses == nil {
+		return VMAgentNotReady{VMName: vmName, Status: "Unknown"}
+	}
+
+	status := "Unknown"
+	for _, s := range *agent.Statuses {
+		if s.DisplayStatus == nil {
+			continue
+		}
+		if *s.DisplayStatus == "Ready" {
+			return nil
+		}
+		status = *s.DisplayStatus
+	}
+
+	return VMAgentNotReady{VMName: vmName, Status: status}
+}
+
+// parseRunCommandResult converts the statuses returned by the Run Command API into a VMRunCommandResult
+func parseRunCommandResult(out compute.RunCommandResult) VMRunCommandResult {
+	if out.Value == nil {
+		return VMRunCommandResult{Succeeded: true}
+	}
+
+	return parseScriptStatuses(*out.Value)
+}
+
+// parseScriptStatuses converts the instance view statuses reported for a script into a VMRunCommandResult.
+// Windows agents return separate StdOut and StdErr component statuses, while Linux agents return a single
+// provisioning status whose message contains [stdout] and [stderr] sections.
+func parseScriptStatuses(statuses []compute.InstanceViewStatus) VMRunCommandResult {
+	result := VMRunCommandResult{Succeeded: true}
+
+	for _, status := range statuses {
+		code := ""
+		if status.Code != nil {
+			code = *status.Code
+		}
+		message := ""
+		if status.Message != nil {
+			message = *status.Message
+		}
+
+		if strings.HasSuffix(code, "/failed") {
+			result.Succeeded = false
+		}
+
+		switch {
+		case strings.HasPrefix(code, "ComponentStatus/StdOut"):
+			result.StdOut = message
+		case strings.HasPrefix(code, "ComponentStatus/StdErr"):
+			result.StdErr = message
+		default:
+			result.StdOut, result.StdErr = splitRunCommandMessage(message)
+			if match := exitStatusRegexp.FindStringSubmatch(message); match != nil {
+				result.ExitCode, _ = strconv.Atoi(match[1])
+			}
+		}
+	}
+
+	return result
+}
+
+// splitRunCommandMessage extracts the [stdout] and [stderr] sections from a Linux Run Command status message
+func splitRunCommandMessage(message string) (string, string) {
+	const stdoutMarker = "[stdout]\n"
+	const stderrMarker = "[stderr]\n"
+
+	stdoutIndex := strings.Index(message, stdoutMarker)
+	stderrIndex := strings.Index(message, stderrMarker)
+	if stdoutIndex < 0 && stderrIndex < 0 {
+		return message, ""
+	}
+
+	stdout := ""
+	stderr := ""
+
+	if stdoutIndex >= 0 {
+		end := len(message)
+		if stderrIndex > stdoutIndex {
+			end = stderrIndex
+		}
+		stdout = message[stdoutIndex+len(stdoutMarker) : end]
+	}
+	if stderrIndex >= 0 {
+		stderr = message[stderrIndex+len(stderrMarker):]
+	}
+
+	return strings.TrimRight(stdout, "\n"), strings.TrimRight(stderr, "\n")
+}
@@ -0,0 +1,68 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRunCommandResult(t *testing.T) {
+	t.Parallel()
+
+	status := func(code string, message string) compute.InstanceViewStatus {
+		return compute.InstanceViewStatus{Code: &code, Message: &message}
+	}
+
+	tests := []struct {
+		name     string
+		statuses []compute.InstanceViewStatus
+		want     VMRunCommandResult
+	}{
+		{
+			name:     "linuxSucceeded",
+			statuses: []compute.InstanceViewStatus{status("ProvisioningState/succeeded", "Enable succeeded: \n[stdout]\nnginx is installed\n\n[stderr]\n")},
+			want:     VMRunCommandResult{StdOut: "nginx is installed", Succeeded: true},
+		},
+		{
+			name:     "linuxFailed",
+			statuses: []compute.InstanceViewStatus{status("ProvisioningState/failed", "Enable failed: failed to execute command: command terminated with exit status=127\n[stdout]\n\n[stderr]\nnginx: command not found\n")},
+			want:     VMRunCommandResult{StdErr: "nginx: command not found", ExitCode: 127},
+		},
+		{
+			name: "windowsSucceeded",
+			statuses: []compute.InstanceViewStatus{
+				status("ComponentStatus/StdOut/succeeded", "IIS is installed"),
+				status("ComponentStatus/StdErr/succeeded", ""),
+			},
+			want: VMRunCommandResult{StdOut: "IIS is installed", Succeeded: true},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := tt.statuses
+			got := parseRunCommandResult(compute.RunCommandResult{Value: &statuses})
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRunShellScriptOnVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := RunShellScriptOnVirtualMachineE(t, rgName, vmName, []string{"echo hello"}, subID)
+
+	require.Error(t, err)
+}
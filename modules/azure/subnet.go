@@ -0,0 +1,169 @@
+package azure
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/assert"
+)
+
+// SubnetConfiguration describes the settings of an Azure Subnet. The association IDs are empty when the subnet has no
+// NSG, Route Table or NAT Gateway.
+type SubnetConfiguration struct {
+	ID              string
+	Name            string
+	AddressPrefixes []string
+
+	NetworkSecurityGroupID string
+	RouteTableID           string
+	NatGatewayID           string
+
+	ServiceEndpoints []SubnetServiceEndpoint
+	Delegations      []SubnetDelegation
+
+	// PrivateEndpointNetworkPolicies and PrivateLinkServiceNetworkPolicies are Enabled or Disabled
+	PrivateEndpointNetworkPolicies    string
+	PrivateLinkServiceNetworkPolicies string
+}
+
+// SubnetServiceEndpoint describes a service endpoint of a subnet, e.g. Microsoft.Storage
+type SubnetServiceEndpoint struct {
+	Service   string
+	Locations []string
+}
+
+// SubnetDelegation describes the delegation of a subnet to a service, e.g. Microsoft.Web/serverFarms
+type SubnetDelegation struct {
+	Name        string
+	ServiceName string
+	Actions     []string
+}
+
+// GetSubnetConfiguration extracts the settings of a Subnet returned by GetSubnetbyNameE
+func GetSubnetConfiguration(subnet network.Subnet) SubnetConfiguration {
+	result := SubnetConfiguration{
+		AddressPrefixes:  GetSubnetAddressPrefixes(subnet),
+		ServiceEndpoints: []SubnetServiceEndpoint{},
+		Delegations:      []SubnetDelegation{},
+	}
+
+	if subnet.ID != nil {
+		result.ID = *subnet.ID
+	}
+	if subnet.Name != nil {
+		result.Name = *subnet.Name
+	}
+
+	props := subnet.SubnetPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.NatGatewayID = getSubResourceID(props.NatGateway)
+	result.PrivateEndpointNetworkPolicies = string(props.PrivateEndpointNetworkPolicies)
+	result.PrivateLinkServiceNetworkPolicies = string(props.PrivateLinkServiceNetworkPolicies)
+
+	if props.NetworkSecurityGroup != nil && props.NetworkSecurityGroup.ID != nil {
+		result.NetworkSecurityGroupID = *props.NetworkSecurityGroup.ID
+	}
+	if props.RouteTable != nil && props.RouteTable.ID != nil {
+		result.RouteTableID = *props.RouteTable.ID
+	}
+	if props.ServiceEndpoints != nil {
+		for _, endpoint := range *props.ServiceEndpoints {
+			result.ServiceEndpoints = append(result.ServiceEndpoints, newSubnetServiceEndpoint(endpoint))
+		}
+	}
+	if props.Delegations != nil {
+		for _, delegation := range *props.Delegations {
+			result.Delegations = append(result.Delegations, newSubnetDelegation(delegation))
+		}
+	}
+
+	return result
+}
+
+// GetSubnetServiceEndpoints returns the services a Subnet has service endpoints for, e.g. Microsoft.Storage
+func GetSubnetServiceEndpoints(subnet network.Subnet) []string {
+	services := []string{}
+
+	for _, endpoint := range GetSubnetConfiguration(subnet).ServiceEndpoints {
+		services = append(services, endpoint.Service)
+	}
+
+	return services
+}
+
+// GetSubnetDelegations returns the services a Subnet is delegated to, e.g. Microsoft.Web/serverFarms
+func GetSubnetDelegations(subnet network.Subnet) []string {
+	services := []string{}
+
+	for _, delegation := range GetSubnetConfiguration(subnet).Delegations {
+		services = append(services, delegation.ServiceName)
+	}
+
+	return services
+}
+
+// AssertSubnetHasServiceEndpoint checks that the given Subnet has a service endpoint for the given service
+func AssertSubnetHasServiceEndpoint(t *testing.T, subnet network.Subnet, service string) {
+	services := GetSubnetServiceEndpoints(subnet)
+
+	assert.True(t, containsFold(services, service), "Check subnet has a service endpoint for %s, found %v", service, services)
+}
+
+// AssertSubnetDelegatedTo checks that the given Subnet is delegated to the given service
+func AssertSubnetDelegatedTo(t *testing.T, subnet network.Subnet, service string) {
+	services := GetSubnetDelegations(subnet)
+
+	assert.True(t, containsFold(services, service), "Check subnet is delegated to %s, found %v", service, services)
+}
+
+// containsFold checks whether a list contains a value, ignoring case as Azure does for provider namespaces
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newSubnetServiceEndpoint converts a service endpoint returned by the API into a SubnetServiceEndpoint
+func newSubnetServiceEndpoint(endpoint network.ServiceEndpointPropertiesFormat) SubnetServiceEndpoint {
+	result := SubnetServiceEndpoint{Locations: []string{}}
+
+	if endpoint.Service != nil {
+		result.Service = *endpoint.Service
+	}
+	if endpoint.Locations != nil {
+		result.Locations = *endpoint.Locations
+	}
+
+	return result
+}
+
+// newSubnetDelegation converts a subnet delegation returned by the API into a SubnetDelegation
+func newSubnetDelegation(delegation network.Delegation) SubnetDelegation {
+	result := SubnetDelegation{Actions: []string{}}
+
+	if delegation.Name != nil {
+		result.Name = *delegation.Name
+	}
+
+	props := delegation.ServiceDelegationPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	if props.ServiceName != nil {
+		result.ServiceName = *props.ServiceName
+	}
+	if props.Actions != nil {
+		result.Actions = *props.Actions
+	}
+
+	return result
+}
@@ -0,0 +1,50 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSubnetConfiguration(t *testing.T) {
+	t.Parallel()
+
+	name := "app-service"
+	prefix := "10.0.3.0/24"
+	natGatewayID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/natGateways/nat"
+	storage := "Microsoft.Storage"
+	delegationName := "webapp"
+	serverFarms := "Microsoft.Web/serverFarms"
+
+	subnet := network.Subnet{
+		Name: &name,
+		SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+			AddressPrefix:    &prefix,
+			NatGateway:       &network.SubResource{ID: &natGatewayID},
+			ServiceEndpoints: &[]network.ServiceEndpointPropertiesFormat{{Service: &storage}},
+			Delegations: &[]network.Delegation{{
+				Name:                              &delegationName,
+				ServiceDelegationPropertiesFormat: &network.ServiceDelegationPropertiesFormat{ServiceName: &serverFarms},
+			}},
+			PrivateEndpointNetworkPolicies: network.VirtualNetworkPrivateEndpointNetworkPoliciesDisabled,
+		},
+	}
+
+	config := GetSubnetConfiguration(subnet)
+	require.Equal(t, []string{"10.0.3.0/24"}, config.AddressPrefixes)
+	require.Equal(t, natGatewayID, config.NatGatewayID)
+	require.Empty(t, config.RouteTableID)
+	require.Equal(t, "Disabled", config.PrivateEndpointNetworkPolicies)
+
+	require.Equal(t, []string{"Microsoft.Storage"}, GetSubnetServiceEndpoints(subnet))
+	require.Equal(t, []string{"Microsoft.Web/serverFarms"}, GetSubnetDelegations(subnet))
+
+	AssertSubnetHasServiceEndpoint(t, subnet, "microsoft.storage")
+	AssertSubnetDelegatedTo(t, subnet, "Microsoft.Web/serverFarms")
+}
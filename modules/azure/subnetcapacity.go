@@ -0,0 +1,172 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SubnetIPUsage describes how many IP addresses of a subnet are in use. TotalIPs excludes the addresses Azure
+// reserves in every subnet.
+type SubnetIPUsage struct {
+	SubnetID   string
+	SubnetName string
+	UsedIPs    int64
+	TotalIPs   int64
+}
+
+// FreeIPs returns the number of IP addresses of the subnet that can still be assigned
+func (usage SubnetIPUsage) FreeIPs() int64 {
+	return usage.TotalIPs - usage.UsedIPs
+}
+
+// IPAddressAvailability describes whether an IP address of a Virtual Network is free. If it is not, Azure suggests
+// some free addresses nearby.
+type IPAddressAvailability struct {
+	Available            bool
+	IsPlatformReserved   bool
+	AvailableIPAddresses []string
+}
+
+// GetSubnetIPUsages gets the IP address usage of every subnet of the given Virtual Network
+func GetSubnetIPUsages(t *testing.T, resGroupName string, vNetName string, subscriptionID string) []SubnetIPUsage {
+	usages, err := GetSubnetIPUsagesE(t, resGroupName, vNetName, subscriptionID)
+	require.NoError(t, err)
+
+	return usages
+}
+
+// GetSubnetIPUsagesE gets the IP address usage of every subnet of the given Virtual Network
+func GetSubnetIPUsagesE(t *testing.T, resGroupName string, vNetName string, subscriptionID string) ([]SubnetIPUsage, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VNet client
+	vnetClient, err := GetVirtualNetworkClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := vnetClient.ListUsageComplete(context.Background(), resGroupName, vNetName)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := []SubnetIPUsage{}
+	for iterator.NotDone() {
+		usages = append(usages, newSubnetIPUsage(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return usages, nil
+}
+
+// GetSubnetIPUsage gets the IP address usage of the given subnet
+func GetSubnetIPUsage(t *testing.T, resGroupName string, vNetName string, sNetName string, subscriptionID string) SubnetIPUsage {
+	usage, err := GetSubnetIPUsageE(t, resGroupName, vNetName, sNetName, subscriptionID)
+	require.NoError(t, err)
+
+	return usage
+}
+
+// GetSubnetIPUsageE gets the IP address usage of the given subnet
+func GetSubnetIPUsageE(t *testing.T, resGroupName string, vNetName string, sNetName string, subscriptionID string) (SubnetIPUsage, error) {
+	usages, err := GetSubnetIPUsagesE(t, resGroupName, vNetName, subscriptionID)
+	if err != nil {
+		return SubnetIPUsage{}, err
+	}
+
+	for _, usage := range usages {
+		if strings.EqualFold(usage.SubnetName, sNetName) {
+			return usage, nil
+		}
+	}
+
+	return SubnetIPUsage{}, SubnetNotFound{VnetName: vNetName, SubnetName: sNetName}
+}
+
+// CheckIPAddressAvailability checks whether the given IP address of a Virtual Network is free to be assigned
+func CheckIPAddressAvailability(t *testing.T, resGroupName string, vNetName string, ipAddress string, subscriptionID string) IPAddressAvailability {
+	availability, err := CheckIPAddressAvailabilityE(t, resGroupName, vNetName, ipAddress, subscriptionID)
+	require.NoError(t, err)
+
+	return availability
+}
+
+// CheckIPAddressAvailabilityE checks whether the given IP address of a Virtual Network is free to be assigned
+func CheckIPAddressAvailabilityE(t *testing.T, resGroupName string, vNetName string, ipAddress string, subscriptionID string) (IPAddressAvailability, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return IPAddressAvailability{}, err
+	}
+
+	// Create a VNet client
+	vnetClient, err := GetVirtualNetworkClient(subscriptionID)
+	if err != nil {
+		return IPAddressAvailability{}, err
+	}
+
+	result, err := vnetClient.CheckIPAddressAvailability(context.Background(), resGroupName, vNetName, ipAddress)
+	if err != nil {
+		return IPAddressAvailability{}, err
+	}
+
+	return newIPAddressAvailability(result), nil
+}
+
+// AssertSubnetHasFreeIPs checks that the given subnet has at least the given number of free IP addresses, e.g. to
+// leave room for AKS nodes and pods to scale out
+func AssertSubnetHasFreeIPs(t *testing.T, resGroupName string, vNetName string, sNetName string, minFreeIPs int64, subscriptionID string) {
+	usage := GetSubnetIPUsage(t, resGroupName, vNetName, sNetName, subscriptionID)
+
+	assert.GreaterOrEqual(t, usage.FreeIPs(), minFreeIPs, "Check subnet %s has at least %d free IPs, %d of %d are in use", sNetName, minFreeIPs, usage.UsedIPs, usage.TotalIPs)
+}
+
+// newSubnetIPUsage converts a VNet usage returned by the API, which is reported per subnet, into a SubnetIPUsage
+func newSubnetIPUsage(usage network.VirtualNetworkUsage) SubnetIPUsage {
+	result := SubnetIPUsage{}
+
+	if usage.ID != nil {
+		result.SubnetID = *usage.ID
+		if id, err := parseAzureResourceID(*usage.ID); err == nil {
+			result.SubnetName = id.Name
+		}
+	}
+	if usage.CurrentValue != nil {
+		result.UsedIPs = int64(*usage.CurrentValue)
+	}
+	if usage.Limit != nil {
+		result.TotalIPs = int64(*usage.Limit)
+	}
+
+	return result
+}
+
+// newIPAddressAvailability converts an IP address availability result returned by the API into an
+// IPAddressAvailability
+func newIPAddressAvailability(result network.IPAddressAvailabilityResult) IPAddressAvailability {
+	availability := IPAddressAvailability{AvailableIPAddresses: []string{}}
+
+	if result.Available != nil {
+		availability.Available = *result.Available
+	}
+	if result.IsPlatformReserved != nil {
+		availability.IsPlatformReserved = *result.IsPlatformReserved
+	}
+	if result.AvailableIPAddresses != nil {
+		availability.AvailableIPAddresses = *result.AvailableIPAddresses
+	}
+
+	return availability
+}
@@ -0,0 +1,50 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSubnetIPUsage(t *testing.T) {
+	t.Parallel()
+
+	subnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/aks-nodes"
+	used := float64(200)
+	limit := float64(251)
+
+	usage := newSubnetIPUsage(network.VirtualNetworkUsage{ID: &subnetID, CurrentValue: &used, Limit: &limit})
+
+	require.Equal(t, SubnetIPUsage{SubnetID: subnetID, SubnetName: "aks-nodes", UsedIPs: 200, TotalIPs: 251}, usage)
+	require.Equal(t, int64(51), usage.FreeIPs())
+}
+
+func TestGetSubnetIPUsageE(t *testing.T) {
+	t.Parallel()
+
+	resGroupName := ""
+	vNetName := ""
+	sNetName := ""
+	subscriptionID := ""
+
+	_, err := GetSubnetIPUsageE(t, resGroupName, vNetName, sNetName, subscriptionID)
+	require.Error(t, err)
+}
+
+func TestCheckIPAddressAvailabilityE(t *testing.T) {
+	t.Parallel()
+
+	resGroupName := ""
+	vNetName := ""
+	ipAddress := ""
+	subscriptionID := ""
+
+	_, err := CheckIPAddressAvailabilityE(t, resGroupName, vNetName, ipAddress, subscriptionID)
+	require.Error(t, err)
+}
@@ -0,0 +1,248 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+// TotalRegionalCoresUsageName is the usage name of the total number of vCPUs across all VM families in a region
+const TotalRegionalCoresUsageName = "cores"
+
+// ComputeUsage describes the current usage and quota limit of a compute resource, such as the vCPUs of a VM family,
+// in a region
+type ComputeUsage struct {
+	// Name is the usage name, e.g. standardDSv3Family or cores
+	Name string
+
+	// LocalizedName is the display name, e.g. Standard DSv3 Family vCPUs
+	LocalizedName string
+
+	Unit         string
+	CurrentValue int64
+	Limit        int64
+}
+
+// Available returns how much of the quota limit is still unused
+func (usage ComputeUsage) Available() int64 {
+	return usage.Limit - usage.CurrentValue
+}
+
+// computeUsageCache holds the compute usages already looked up in this test session, keyed by subscription and region
+var computeUsageCache = struct {
+	sync.Mutex
+	usages map[string][]ComputeUsage
+}{usages: make(map[string][]ComputeUsage)}
+
+// ClearComputeUsageCache drops the compute usages cached by GetComputeUsages, so the next lookup queries Azure again
+func ClearComputeUsageCache() {
+	computeUsageCache.Lock()
+	defer computeUsageCache.Unlock()
+
+	computeUsageCache.usages = make(map[string][]ComputeUsage)
+}
+
+// GetComputeUsageClient is a helper function that will setup an Azure Compute Usage client on your behalf
+func GetComputeUsageClient(subscriptionID string) (*compute.UsageClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a Usage client
+	usageClient := compute.NewUsageClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	usageClient.Authorizer = *authorizer
+
+	return &usageClient, nil
+}
+
+// GetComputeUsages gets the current usage and limit of every compute quota in the given region. Results are cached for
+// the rest of the test session.
+func GetComputeUsages(t *testing.T, region string, subscriptionID string) []ComputeUsage {
+	usages, err := GetComputeUsagesE(t, region, subscriptionID)
+	require.NoError(t, err)
+
+	return usages
+}
+
+// GetComputeUsagesE gets the current usage and limit of every compute quota in the given region. Results are cached for
+// the rest of the test session.
+func GetComputeUsagesE(t *testing.T, region string, subscriptionID string) ([]ComputeUsage, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := subscriptionID + "/" + normalizeRegionName(region)
+
+	// Query Azure without holding the lock, so lookups of other regions are not held up. Two concurrent lookups of the
+	// same region may both query Azure, and the later one wins.
+	usages, ok := getCachedComputeUsages(cacheKey)
+	if ok {
+		return usages, nil
+	}
+
+	usages, err = listComputeUsagesE(region, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	computeUsageCache.Lock()
+	computeUsageCache.usages[cacheKey] = usages
+	computeUsageCache.Unlock()
+
+	return append([]ComputeUsage{}, usages...), nil
+}
+
+// getCachedComputeUsages returns a copy of the cached compute usages for the given key, so callers cannot modify the cache
+func getCachedComputeUsages(cacheKey string) ([]ComputeUsage, bool) {
+	computeUsageCache.Lock()
+	defer computeUsageCache.Unlock()
+
+	usages, ok := computeUsageCache.usages[cacheKey]
+	if !ok {
+		return nil, false
+	}
+
+	return append([]ComputeUsage{}, usages...), true
+}
+
+// listComputeUsagesE lists every compute usage of the given region
+func listComputeUsagesE(region string, subscriptionID string) ([]ComputeUsage, error) {
+	// Create a Usage client
+	usageClient, err := GetComputeUsageClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := usageClient.ListComplete(context.Background(), normalizeRegionName(region))
+	if err != nil {
+		return nil, err
+	}
+
+	usages := []ComputeUsage{}
+	for iterator.NotDone() {
+		usages = append(usages, newComputeUsage(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return usages, nil
+}
+
+// GetComputeUsage gets the current usage and limit of a single compute quota, e.g. standardDSv3Family, in the given region
+func GetComputeUsage(t *testing.T, region string, family string, subscriptionID string) ComputeUsage {
+	usage, err := GetComputeUsageE(t, region, family, subscriptionID)
+	require.NoError(t, err)
+
+	return usage
+}
+
+// GetComputeUsageE gets the current usage and limit of a single compute quota, e.g. standardDSv3Family, in the given region
+func GetComputeUsageE(t *testing.T, region string, family string, subscriptionID string) (ComputeUsage, error) {
+	usages, err := GetComputeUsagesE(t, region, subscriptionID)
+	if err != nil {
+		return ComputeUsage{}, err
+	}
+
+	return findComputeUsage(usages, region, family)
+}
+
+// RequireQuotaHeadroom fails the test immediately unless the given region has quota for at least the given number of
+// additional cores in the VM family, as well as in the total regional vCPU quota. The subscription is taken from the
+// ARM_SUBSCRIPTION_ID environment variable.
+func RequireQuotaHeadroom(t *testing.T, region string, family string, cores int64) {
+	err := CheckQuotaHeadroomE(t, region, family, cores, "")
+	require.NoError(t, err)
+}
+
+// CheckQuotaHeadroomE returns a QuotaHeadroomInsufficient error unless the given region has quota for at least the
+// given number of additional cores in the VM family, as well as in the total regional vCPU quota
+func CheckQuotaHeadroomE(t *testing.T, region string, family string, cores int64, subscriptionID string) error {
+	usages, err := GetComputeUsagesE(t, region, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return checkQuotaHeadroom(usages, region, family, cores)
+}
+
+// checkQuotaHeadroom checks the given usages have room for the given number of cores in the VM family and in the total
+// regional vCPU quota
+func checkQuotaHeadroom(usages []ComputeUsage, region string, family string, cores int64) error {
+	families := []string{family}
+	if !strings.EqualFold(family, TotalRegionalCoresUsageName) {
+		families = append(families, TotalRegionalCoresUsageName)
+	}
+
+	for _, name := range families {
+		usage, err := findComputeUsage(usages, region, name)
+		if err != nil {
+			return err
+		}
+
+		if usage.Available() < cores {
+			return QuotaHeadroomInsufficient{
+				Region:    region,
+				Family:    usage.Name,
+				Current:   usage.CurrentValue,
+				Limit:     usage.Limit,
+				Requested: cores,
+			}
+		}
+	}
+
+	return nil
+}
+
+// findComputeUsage finds the usage with the given name or display name, ignoring case
+func findComputeUsage(usages []ComputeUsage, region string, family string) (ComputeUsage, error) {
+	for _, usage := range usages {
+		if strings.EqualFold(usage.Name, family) || strings.EqualFold(usage.LocalizedName, family) {
+			return usage, nil
+		}
+	}
+
+	return ComputeUsage{}, ComputeUsageNotFound{Region: region, Family: family}
+}
+
+// newComputeUsage converts a usage returned by the API into a ComputeUsage
+func newComputeUsage(usage compute.Usage) ComputeUsage {
+	result := ComputeUsage{}
+
+	if usage.Name != nil {
+		if usage.Name.Value != nil {
+			result.Name = *usage.Name.Value
+		}
+		if usage.Name.LocalizedValue != nil {
+			result.LocalizedName = *usage.Name.LocalizedValue
+		}
+	}
+	if usage.Unit != nil {
+		result.Unit = *usage.Unit
+	}
+	if usage.CurrentValue != nil {
+		result.CurrentValue = int64(*usage.CurrentValue)
+	}
+	if usage.Limit != nil {
+		result.Limit = *usage.Limit
+	}
+
+	return result
+}
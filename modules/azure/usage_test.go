@@ -0,0 +1,71 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQuotaHeadroom(t *testing.T) {
+	t.Parallel()
+
+	usages := []ComputeUsage{
+		{Name: "cores", LocalizedName: "Total Regional vCPUs", CurrentValue: 20, Limit: 100},
+		{Name: "standardDSv3Family", LocalizedName: "Standard DSv3 Family vCPUs", CurrentValue: 90, Limit: 100},
+		{Name: "standardFSv2Family", LocalizedName: "Standard FSv2 Family vCPUs", CurrentValue: 0, Limit: 200},
+	}
+
+	tests := []struct {
+		name   string
+		family string
+		cores  int64
+		want   error
+	}{
+		{name: "FamilyHasRoom", family: "standardDSv3Family", cores: 10, want: nil},
+		{name: "FamilyMatchedIgnoringCase", family: "StandardDSv3Family", cores: 4, want: nil},
+		{name: "FamilyExhausted", family: "standardDSv3Family", cores: 16, want: QuotaHeadroomInsufficient{Region: "eastus", Family: "standardDSv3Family", Current: 90, Limit: 100, Requested: 16}},
+		{name: "RegionalCoresExhausted", family: "standardFSv2Family", cores: 96, want: QuotaHeadroomInsufficient{Region: "eastus", Family: "cores", Current: 20, Limit: 100, Requested: 96}},
+		{name: "UnknownFamily", family: "standardNCv3Family", cores: 1, want: ComputeUsageNotFound{Region: "eastus", Family: "standardNCv3Family"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQuotaHeadroom(usages, "eastus", tt.family, tt.cores)
+
+			require.Equal(t, tt.want, err)
+		})
+	}
+}
+
+func TestGetCachedComputeUsagesReturnsCopy(t *testing.T) {
+	t.Parallel()
+
+	cacheKey := "cache-test-sub/eastus"
+
+	computeUsageCache.Lock()
+	computeUsageCache.usages[cacheKey] = []ComputeUsage{{Name: "cores", CurrentValue: 20, Limit: 100}}
+	computeUsageCache.Unlock()
+
+	usages, ok := getCachedComputeUsages(cacheKey)
+	require.True(t, ok)
+	usages[0].CurrentValue = 99
+
+	usages, ok = getCachedComputeUsages(cacheKey)
+	require.True(t, ok)
+	require.Equal(t, int64(20), usages[0].CurrentValue)
+
+	_, ok = getCachedComputeUsages("cache-test-sub/westeurope")
+	require.False(t, ok)
+}
+
+func TestGetComputeUsagesE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetComputeUsagesE(t, "", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,313 @@
+package azure
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// VMCustomDataSet means the Virtual Machine model carries custom data
+	VMCustomDataSet = "Set"
+
+	// VMCustomDataUnknown means the Virtual Machine model carries no custom data. Azure never returns custom data for an
+	// existing Virtual Machine, so this does not mean none was set at creation.
+	VMCustomDataUnknown = "Unknown"
+)
+
+// VMOSProfile holds the OS profile settings of a Virtual Machine that matter for its security posture
+type VMOSProfile struct {
+	ComputerName  string
+	AdminUsername string
+
+	// OSType is "Linux" or "Windows", based on which OS configuration the Virtual Machine has
+	OSType string
+
+	// Linux settings
+	DisablePasswordAuthentication bool
+	SSHPublicKeys                 []string
+
+	// Windows settings
+	EnableAutomaticUpdates bool
+	TimeZone               string
+
+	// PatchMode is the guest patching mode of the Linux or Windows configuration, e.g. AutomaticByPlatform
+	PatchMode        string
+	ProvisionVMAgent bool
+
+	// CustomData is VMCustomDataSet or VMCustomDataUnknown. It can only be VMCustomDataSet when the profile is built
+	// from a Virtual Machine model that has not been fetched from the API, e.g. one about to be deployed, so use
+	// HasUserData to check a deployed Virtual Machine.
+	CustomData string
+
+	// HasUserData is true when user data is set on the Virtual Machine
+	HasUserData bool
+}
+
+// VMSecurityProfile holds the trusted launch and host encryption settings of a Virtual Machine
+type VMSecurityProfile struct {
+	// SecurityType is e.g. TrustedLaunch or ConfidentialVM, or empty for a standard Virtual Machine
+	SecurityType      string
+	SecureBootEnabled bool
+	VTpmEnabled       bool
+	EncryptionAtHost  bool
+}
+
+// VMIdentity holds the managed identity settings of a Virtual Machine
+type VMIdentity struct {
+	// Type is e.g. SystemAssigned, UserAssigned, "SystemAssigned, UserAssigned" or None
+	Type string
+
+	// PrincipalID is the principal ID of the system assigned identity, if enabled
+	PrincipalID string
+	TenantID    string
+
+	// UserAssignedIdentityIDs are the resource IDs of the user assigned identities attached to the Virtual Machine
+	UserAssignedIdentityIDs []string
+}
+
+// GetOSProfileFromVirtualMachine extracts the OS profile settings from the given Virtual Machine
+func GetOSProfileFromVirtualMachine(vm compute.VirtualMachine) VMOSProfile {
+	profile := VMOSProfile{SSHPublicKeys: []string{}, CustomData: VMCustomDataUnknown}
+
+	props := vm.VirtualMachineProperties
+	if props == nil {
+		return profile
+	}
+
+	profile.HasUserData = props.UserData != nil && *props.UserData != ""
+
+	osProfile := props.OsProfile
+	if osProfile == nil {
+		return profile
+	}
+
+	if osProfile.ComputerName != nil {
+		profile.ComputerName = *osProfile.ComputerName
+	}
+	if osProfile.AdminUsername != nil {
+		profile.AdminUsername = *osProfile.AdminUsername
+	}
+	if osProfile.CustomData != nil && *osProfile.CustomData != "" {
+		profile.CustomData = VMCustomDataSet
+	}
+
+	if linux := osProfile.LinuxConfiguration; linux != nil {
+		profile.OSType = string(compute.OperatingSystemTypesLinux)
+
+		if linux.DisablePasswordAuthentication != nil {
+			profile.DisablePasswordAuthentication = *linux.DisablePasswordAuthentication
+		}
+		if linux.ProvisionVMAgent != nil {
+			profile.ProvisionVMAgent = *linux.ProvisionVMAgent
+		}
+		if linux.PatchSettings != nil {
+			profile.PatchMode = string(linux.PatchSettings.PatchMode)
+		}
+		if linux.SSH != nil && linux.SSH.PublicKeys != nil {
+			for _, key := range *linux.SSH.PublicKeys {
+				if key.KeyData != nil {
+					profile.SSHPublicKeys = append(profile.SSHPublicKeys, *key.KeyData)
+				}
+			}
+		}
+	}
+
+	if windows := osProfile.WindowsConfiguration; windows != nil {
+		profile.OSType = string(compute.OperatingSystemTypesWindows)
+
+		if windows.EnableAutomaticUpdates != nil {
+			profile.EnableAutomaticUpdates = *windows.EnableAutomaticUpdates
+		}
+		if windows.ProvisionVMAgent != nil {
+			profile.ProvisionVMAgent = *windows.ProvisionVMAgent
+		}
+		if windows.TimeZone != nil {
+			profile.TimeZone = *windows.TimeZone
+		}
+		if windows.PatchSettings != nil {
+			profile.PatchMode = string(windows.PatchSettings.PatchMode)
+		}
+	}
+
+	return profile
+}
+
+// GetSecurityProfileFromVirtualMachine extracts the trusted launch and host encryption settings from the given Virtual Machine
+func GetSecurityProfileFromVirtualMachine(vm compute.VirtualMachine) VMSecurityProfile {
+	profile := VMSecurityProfile{}
+
+	if vm.VirtualMachineProperties == nil || vm.SecurityProfile == nil {
+		return profile
+	}
+	security := vm.SecurityProfile
+
+	profile.SecurityType = string(security.SecurityType)
+	if security.EncryptionAtHost != nil {
+		profile.EncryptionAtHost = *security.EncryptionAtHost
+	}
+	if security.UefiSettings != nil {
+		if security.UefiSettings.SecureBootEnabled != nil {
+			profile.SecureBootEnabled = *security.UefiSettings.SecureBootEnabled
+		}
+		if security.UefiSettings.VTpmEnabled != nil {
+			profile.VTpmEnabled = *security.UefiSettings.VTpmEnabled
+		}
+	}
+
+	return profile
+}
+
+// GetIdentityFromVirtualMachine extracts the managed identity settings from the given Virtual Machine
+func GetIdentityFromVirtualMachine(vm compute.VirtualMachine) VMIdentity {
+	identity := VMIdentity{UserAssignedIdentityIDs: []string{}}
+
+	if vm.Identity == nil {
+		return identity
+	}
+
+	identity.Type = string(vm.Identity.Type)
+	if vm.Identity.PrincipalID != nil {
+		identity.PrincipalID = *vm.Identity.PrincipalID
+	}
+	if vm.Identity.TenantID != nil {
+		identity.TenantID = *vm.Identity.TenantID
+	}
+	for id := range vm.Identity.UserAssignedIdentities {
+		identity.UserAssignedIdentityIDs = append(identity.UserAssignedIdentityIDs, id)
+	}
+	sort.Strings(identity.UserAssignedIdentityIDs)
+
+	return identity
+}
+
+// GetOSProfileOfVirtualMachine gets the OS profile settings of the given Virtual Machine
+func GetOSProfileOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) VMOSProfile {
+	profile, err := GetOSProfileOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return profile
+}
+
+// GetOSProfileOfVirtualMachineE gets the OS profile settings of the given Virtual Machine
+func GetOSProfileOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (VMOSProfile, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return VMOSProfile{}, err
+	}
+
+	// Create a VM client
+	vmClient, err := GetVirtualMachineClient(subscriptionID)
+	if err != nil {
+		return VMOSProfile{}, err
+	}
+
+	// Get the details of the target virtual machine, including its user data
+	vm, err := vmClient.Get(context.Background(), resGroupName, vmName, compute.InstanceViewTypesUserData)
+	if err != nil {
+		return VMOSProfile{}, err
+	}
+
+	return GetOSProfileFromVirtualMachine(vm), nil
+}
+
+// GetSecurityProfileOfVirtualMachine gets the trusted launch and host encryption settings of the given Virtual Machine
+func GetSecurityProfileOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) VMSecurityProfile {
+	profile, err := GetSecurityProfileOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return profile
+}
+
+// GetSecurityProfileOfVirtualMachineE gets the trusted launch and host encryption settings of the given Virtual Machine
+func GetSecurityProfileOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (VMSecurityProfile, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return VMSecurityProfile{}, err
+	}
+
+	return GetSecurityProfileFromVirtualMachine(vm), nil
+}
+
+// GetIdentityOfVirtualMachine gets the managed identity settings of the given Virtual Machine
+func GetIdentityOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) VMIdentity {
+	identity, err := GetIdentityOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return identity
+}
+
+// GetIdentityOfVirtualMachineE gets the managed identity settings of the given Virtual Machine
+func GetIdentityOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (VMIdentity, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return VMIdentity{}, err
+	}
+
+	return GetIdentityFromVirtualMachine(vm), nil
+}
+
+// AssertPasswordAuthenticationDisabled checks that the given Linux OS profile only allows SSH key authentication
+func AssertPasswordAuthenticationDisabled(t *testing.T, profile VMOSProfile) {
+	assert.Equal(t, string(compute.OperatingSystemTypesLinux), profile.OSType, "Check if VM is a Linux VM")
+	assert.True(t, profile.DisablePasswordAuthentication, "Check if password authentication is disabled")
+}
+
+// AssertOnlyApprovedSSHKeys checks that every SSH public key on the given OS profile is in the approved list. Keys are
+// compared ignoring surrounding whitespace.
+func AssertOnlyApprovedSSHKeys(t *testing.T, profile VMOSProfile, approvedKeys []string) {
+	approved := make(map[string]bool)
+	for _, key := range approvedKeys {
+		approved[strings.TrimSpace(key)] = true
+	}
+
+	for _, key := range profile.SSHPublicKeys {
+		assert.True(t, approved[strings.TrimSpace(key)], "SSH public key %q on %s is not approved", key, profile.ComputerName)
+	}
+}
+
+// AssertAutomaticUpdatesEnabled checks that automatic updates are enabled on the given Windows OS profile
+func AssertAutomaticUpdatesEnabled(t *testing.T, profile VMOSProfile) {
+	assert.Equal(t, string(compute.OperatingSystemTypesWindows), profile.OSType, "Check if VM is a Windows VM")
+	assert.True(t, profile.EnableAutomaticUpdates, "Check if automatic updates are enabled")
+}
+
+// AssertPatchMode checks that the guest patching mode of the given OS profile matches the expected mode
+func AssertPatchMode(t *testing.T, profile VMOSProfile, expectedPatchMode string) {
+	assert.Equal(t, expectedPatchMode, profile.PatchMode, "Check VM patch mode")
+}
+
+// AssertTrustedLaunchEnabled checks that the given security profile uses trusted launch with secure boot and vTPM enabled
+func AssertTrustedLaunchEnabled(t *testing.T, profile VMSecurityProfile) {
+	assert.Equal(t, string(compute.SecurityTypesTrustedLaunch), profile.SecurityType, "Check if trusted launch is enabled")
+	assert.True(t, profile.SecureBootEnabled, "Check if secure boot is enabled")
+	assert.True(t, profile.VTpmEnabled, "Check if vTPM is enabled")
+}
+
+// AssertEncryptionAtHostEnabled checks that encryption at host is enabled on the given security profile
+func AssertEncryptionAtHostEnabled(t *testing.T, profile VMSecurityProfile) {
+	assert.True(t, profile.EncryptionAtHost, "Check if encryption at host is enabled")
+}
+
+// AssertSystemAssignedIdentityEnabled checks that the given identity settings include a system assigned identity
+func AssertSystemAssignedIdentityEnabled(t *testing.T, identity VMIdentity) {
+	assert.Contains(t, identity.Type, string(compute.ResourceIdentityTypeSystemAssigned), "Check if system assigned identity is enabled")
+	assert.NotEmpty(t, identity.PrincipalID, "Check if system assigned identity has a principal ID")
+}
+
+// AssertUserAssignedIdentityAttached checks that the user assigned identity with the given resource ID is attached
+func AssertUserAssignedIdentityAttached(t *testing.T, identity VMIdentity, identityID string) {
+	for _, id := range identity.UserAssignedIdentityIDs {
+		if strings.EqualFold(id, identityID) {
+			return
+		}
+	}
+
+	assert.Fail(t, "User assigned identity not attached", "Identity %s is not attached, found %v", identityID, identity.UserAssignedIdentityIDs)
+}
@@ -0,0 +1,115 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOSProfileFromVirtualMachine(t *testing.T) {
+	t.Parallel()
+
+	computerName := "vm-linux"
+	adminUsername := "azureuser"
+	disablePassword := true
+	keyData := "ssh-rsa AAAAB3Nza test@example"
+
+	vm := compute.VirtualMachine{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			OsProfile: &compute.OSProfile{
+				ComputerName:  &computerName,
+				AdminUsername: &adminUsername,
+				LinuxConfiguration: &compute.LinuxConfiguration{
+					DisablePasswordAuthentication: &disablePassword,
+					SSH: &compute.SSHConfiguration{
+						PublicKeys: &[]compute.SSHPublicKey{{KeyData: &keyData}},
+					},
+					PatchSettings: &compute.LinuxPatchSettings{PatchMode: compute.LinuxVMGuestPatchModeAutomaticByPlatform},
+				},
+			},
+		},
+	}
+
+	profile := GetOSProfileFromVirtualMachine(vm)
+
+	require.Equal(t, "Linux", profile.OSType)
+	require.Equal(t, "azureuser", profile.AdminUsername)
+	require.True(t, profile.DisablePasswordAuthentication)
+	require.Equal(t, []string{keyData}, profile.SSHPublicKeys)
+	require.Equal(t, "AutomaticByPlatform", profile.PatchMode)
+	require.Equal(t, VMCustomDataUnknown, profile.CustomData)
+	require.False(t, profile.HasUserData)
+
+	AssertPasswordAuthenticationDisabled(t, profile)
+	AssertOnlyApprovedSSHKeys(t, profile, []string{keyData + "\n"})
+}
+
+func TestGetOSProfileFromVirtualMachineModelWithCustomData(t *testing.T) {
+	t.Parallel()
+
+	customData := "I2Nsb3VkLWNvbmZpZw=="
+	userData := "ZWNobyBoZWxsbw=="
+
+	vm := compute.VirtualMachine{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			UserData:  &userData,
+			OsProfile: &compute.OSProfile{CustomData: &customData},
+		},
+	}
+
+	profile := GetOSProfileFromVirtualMachine(vm)
+
+	require.Equal(t, VMCustomDataSet, profile.CustomData)
+	require.True(t, profile.HasUserData)
+}
+
+func TestGetSecurityProfileFromVirtualMachine(t *testing.T) {
+	t.Parallel()
+
+	enabled := true
+
+	vm := compute.VirtualMachine{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			SecurityProfile: &compute.SecurityProfile{
+				SecurityType:     compute.SecurityTypesTrustedLaunch,
+				EncryptionAtHost: &enabled,
+				UefiSettings:     &compute.UefiSettings{SecureBootEnabled: &enabled, VTpmEnabled: &enabled},
+			},
+		},
+	}
+
+	profile := GetSecurityProfileFromVirtualMachine(vm)
+
+	require.Equal(t, VMSecurityProfile{SecurityType: "TrustedLaunch", SecureBootEnabled: true, VTpmEnabled: true, EncryptionAtHost: true}, profile)
+
+	AssertTrustedLaunchEnabled(t, profile)
+	AssertEncryptionAtHostEnabled(t, profile)
+}
+
+func TestGetIdentityFromVirtualMachine(t *testing.T) {
+	t.Parallel()
+
+	principalID := "00000000-0000-0000-0000-000000000001"
+	identityID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id-app"
+
+	vm := compute.VirtualMachine{
+		Identity: &compute.VirtualMachineIdentity{
+			Type:                   compute.ResourceIdentityTypeSystemAssignedUserAssigned,
+			PrincipalID:            &principalID,
+			UserAssignedIdentities: map[string]*compute.UserAssignedIdentitiesValue{identityID: {}},
+		},
+	}
+
+	identity := GetIdentityFromVirtualMachine(vm)
+
+	require.Equal(t, []string{identityID}, identity.UserAssignedIdentityIDs)
+
+	AssertSystemAssignedIdentityEnabled(t, identity)
+	AssertUserAssignedIdentityAttached(t, identity, identityID)
+}
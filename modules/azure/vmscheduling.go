@@ -0,0 +1,201 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// VMSchedulingProfile holds the settings that decide where and how a Virtual Machine (or the instances of a Virtual
+// Machine Scale Set) get capacity: spot priority, eviction, max price, ultra disk capability, dedicated hosts and
+// capacity reservations
+type VMSchedulingProfile struct {
+	// Priority is Regular, Low or Spot
+	Priority string
+
+	// EvictionPolicy is Deallocate or Delete for Spot instances, or empty otherwise
+	EvictionPolicy string
+
+	// MaxPrice is the maximum price in US Dollars a Spot instance is billed at. A value of -1 means the instance is
+	// billed up to the on-demand price and is never evicted for price reasons.
+	MaxPrice float64
+
+	UltraSSDEnabled bool
+
+	// HostGroupID and HostID are the dedicated host group and dedicated host the Virtual Machine is assigned to, if any
+	HostGroupID string
+	HostID      string
+
+	CapacityReservationGroupID string
+}
+
+// IsSpot returns true when the profile uses Spot priority
+func (profile VMSchedulingProfile) IsSpot() bool {
+	return profile.Priority == string(compute.Spot)
+}
+
+// GetSchedulingProfileFromVirtualMachine extracts the scheduling settings from the given Virtual Machine
+func GetSchedulingProfileFromVirtualMachine(vm compute.VirtualMachine) VMSchedulingProfile {
+	profile := VMSchedulingProfile{}
+
+	props := vm.VirtualMachineProperties
+	if props == nil {
+		return profile
+	}
+
+	profile.Priority = string(props.Priority)
+	profile.EvictionPolicy = string(props.EvictionPolicy)
+
+	if props.BillingProfile != nil && props.BillingProfile.MaxPrice != nil {
+		profile.MaxPrice = *props.BillingProfile.MaxPrice
+	}
+	if props.AdditionalCapabilities != nil && props.AdditionalCapabilities.UltraSSDEnabled != nil {
+		profile.UltraSSDEnabled = *props.AdditionalCapabilities.UltraSSDEnabled
+	}
+	if props.HostGroup != nil && props.HostGroup.ID != nil {
+		profile.HostGroupID = *props.HostGroup.ID
+	}
+	if props.Host != nil && props.Host.ID != nil {
+		profile.HostID = *props.Host.ID
+	}
+	if props.CapacityReservation != nil && props.CapacityReservation.CapacityReservationGroup != nil &&
+		props.CapacityReservation.CapacityReservationGroup.ID != nil {
+		profile.CapacityReservationGroupID = *props.CapacityReservation.CapacityReservationGroup.ID
+	}
+
+	return profile
+}
+
+// GetSchedulingProfileFromVirtualMachineScaleSet extracts the scheduling settings from the given Virtual Machine Scale Set
+func GetSchedulingProfileFromVirtualMachineScaleSet(vmss compute.VirtualMachineScaleSet) VMSchedulingProfile {
+	profile := VMSchedulingProfile{}
+
+	props := vmss.VirtualMachineScaleSetProperties
+	if props == nil {
+		return profile
+	}
+
+	if props.AdditionalCapabilities != nil && props.AdditionalCapabilities.UltraSSDEnabled != nil {
+		profile.UltraSSDEnabled = *props.AdditionalCapabilities.UltraSSDEnabled
+	}
+	if props.HostGroup != nil && props.HostGroup.ID != nil {
+		profile.HostGroupID = *props.HostGroup.ID
+	}
+
+	vmProfile := props.VirtualMachineProfile
+	if vmProfile == nil {
+		return profile
+	}
+
+	profile.Priority = string(vmProfile.Priority)
+	profile.EvictionPolicy = string(vmProfile.EvictionPolicy)
+
+	if vmProfile.BillingProfile != nil && vmProfile.BillingProfile.MaxPrice != nil {
+		profile.MaxPrice = *vmProfile.BillingProfile.MaxPrice
+	}
+	if vmProfile.CapacityReservation != nil && vmProfile.CapacityReservation.CapacityReservationGroup != nil &&
+		vmProfile.CapacityReservation.CapacityReservationGroup.ID != nil {
+		profile.CapacityReservationGroupID = *vmProfile.CapacityReservation.CapacityReservationGroup.ID
+	}
+
+	return profile
+}
+
+// GetSchedulingProfileOfVirtualMachine gets the spot, ultra disk, dedicated host and capacity reservation settings of the given Virtual Machine
+func GetSchedulingProfileOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) VMSchedulingProfile {
+	profile, err := GetSchedulingProfileOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return profile
+}
+
+// GetSchedulingProfileOfVirtualMachineE gets the spot, ultra disk, dedicated host and capacity reservation settings of the given Virtual Machine
+func GetSchedulingProfileOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (VMSchedulingProfile, error) {
+	vm, err := GetVMbyNameE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return VMSchedulingProfile{}, err
+	}
+
+	return GetSchedulingProfileFromVirtualMachine(vm), nil
+}
+
+// GetSchedulingProfileOfVirtualMachineScaleSet gets the spot, ultra disk, dedicated host and capacity reservation settings of the given Virtual Machine Scale Set
+func GetSchedulingProfileOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) VMSchedulingProfile {
+	profile, err := GetSchedulingProfileOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return profile
+}
+
+// GetSchedulingProfileOfVirtualMachineScaleSetE gets the spot, ultra disk, dedicated host and capacity reservation settings of the given Virtual Machine Scale Set
+func GetSchedulingProfileOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) (VMSchedulingProfile, error) {
+	vmss, err := GetVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return VMSchedulingProfile{}, err
+	}
+
+	return GetSchedulingProfileFromVirtualMachineScaleSet(vmss), nil
+}
+
+// GetPriorityOfVirtualMachine gets the priority (Regular, Low or Spot) of the given Virtual Machine
+func GetPriorityOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) string {
+	priority, err := GetPriorityOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return priority
+}
+
+// GetPriorityOfVirtualMachineE gets the priority (Regular, Low or Spot) of the given Virtual Machine
+func GetPriorityOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (string, error) {
+	profile, err := GetSchedulingProfileOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	return profile.Priority, nil
+}
+
+// GetEvictionPolicyOfVirtualMachine gets the eviction policy (Deallocate or Delete) of the given Spot Virtual Machine
+func GetEvictionPolicyOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) string {
+	policy, err := GetEvictionPolicyOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return policy
+}
+
+// GetEvictionPolicyOfVirtualMachineE gets the eviction policy (Deallocate or Delete) of the given Spot Virtual Machine
+func GetEvictionPolicyOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (string, error) {
+	profile, err := GetSchedulingProfileOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	return profile.EvictionPolicy, nil
+}
+
+// GetMaxPriceOfVirtualMachine gets the maximum price of the given Spot Virtual Machine, where -1 means on-demand price
+func GetMaxPriceOfVirtualMachine(t *testing.T, resGroupName string, vmName string, subscriptionID string) float64 {
+	maxPrice, err := GetMaxPriceOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	require.NoError(t, err)
+
+	return maxPrice
+}
+
+// GetMaxPriceOfVirtualMachineE gets the maximum price of the given Spot Virtual Machine, where -1 means on-demand price
+func GetMaxPriceOfVirtualMachineE(t *testing.T, resGroupName string, vmName string, subscriptionID string) (float64, error) {
+	profile, err := GetSchedulingProfileOfVirtualMachineE(t, resGroupName, vmName, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return profile.MaxPrice, nil
+}
+
+// AssertSpotConfiguration checks that the given scheduling profile uses Spot priority with the expected eviction policy and max price
+func AssertSpotConfiguration(t *testing.T, profile VMSchedulingProfile, expectedEvictionPolicy string, expectedMaxPrice float64) {
+	assert.True(t, profile.IsSpot(), "Check if Spot priority is used, got %q", profile.Priority)
+	assert.Equal(t, expectedEvictionPolicy, profile.EvictionPolicy, "Check Spot eviction policy")
+	assert.Equal(t, expectedMaxPrice, profile.MaxPrice, "Check Spot max price")
+}
@@ -0,0 +1,90 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSchedulingProfileFromVirtualMachine(t *testing.T) {
+	t.Parallel()
+
+	maxPrice := 0.05
+	hostID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/hostGroups/hg/hosts/host1"
+	reservationGroupID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/capacityReservationGroups/crg"
+
+	vm := compute.VirtualMachine{
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			Priority:            compute.Spot,
+			EvictionPolicy:      compute.VirtualMachineEvictionPolicyTypesDelete,
+			BillingProfile:      &compute.BillingProfile{MaxPrice: &maxPrice},
+			Host:                &compute.SubResource{ID: &hostID},
+			CapacityReservation: &compute.CapacityReservationProfile{CapacityReservationGroup: &compute.SubResource{ID: &reservationGroupID}},
+		},
+	}
+
+	profile := GetSchedulingProfileFromVirtualMachine(vm)
+
+	require.Equal(t, VMSchedulingProfile{
+		Priority:                   "Spot",
+		EvictionPolicy:             "Delete",
+		MaxPrice:                   0.05,
+		HostID:                     hostID,
+		CapacityReservationGroupID: reservationGroupID,
+	}, profile)
+	require.True(t, profile.IsSpot())
+
+	AssertSpotConfiguration(t, profile, "Delete", 0.05)
+
+	require.Equal(t, VMSchedulingProfile{}, GetSchedulingProfileFromVirtualMachine(compute.VirtualMachine{}))
+}
+
+func TestGetSchedulingProfileOfVirtualMachineE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmName := ""
+	subID := ""
+
+	_, err := GetSchedulingProfileOfVirtualMachineE(t, rgName, vmName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetSchedulingProfileFromVirtualMachineScaleSet(t *testing.T) {
+	t.Parallel()
+
+	maxPrice := float64(-1)
+	ultraSSD := true
+	hostGroupID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/hostGroups/hg"
+
+	vmss := compute.VirtualMachineScaleSet{
+		VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+			AdditionalCapabilities: &compute.AdditionalCapabilities{UltraSSDEnabled: &ultraSSD},
+			HostGroup:              &compute.SubResource{ID: &hostGroupID},
+			VirtualMachineProfile: &compute.VirtualMachineScaleSetVMProfile{
+				Priority:       compute.Spot,
+				EvictionPolicy: compute.VirtualMachineEvictionPolicyTypesDeallocate,
+				BillingProfile: &compute.BillingProfile{MaxPrice: &maxPrice},
+			},
+		},
+	}
+
+	profile := GetSchedulingProfileFromVirtualMachineScaleSet(vmss)
+
+	require.Equal(t, VMSchedulingProfile{
+		Priority:        "Spot",
+		EvictionPolicy:  "Deallocate",
+		MaxPrice:        -1,
+		UltraSSDEnabled: true,
+		HostGroupID:     hostGroupID,
+	}, profile)
+
+	AssertSpotConfiguration(t, profile, "Deallocate", -1)
+}
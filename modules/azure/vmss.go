@@ -0,0 +1,402 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+// VMSSInstance holds the provisioning, power and health state of a single Virtual Machine Scale Set instance
+type VMSSInstance struct {
+	InstanceID         string
+	Name               string
+	Zones              []string
+	ProvisioningState  string
+	LatestModelApplied bool
+
+	// PowerState is the power state reported by the instance view, e.g. "running" or "deallocated"
+	PowerState string
+
+	// HealthState is the state reported by the Application Health extension, e.g. "healthy" or "unhealthy".
+	// It is empty when the extension is not installed on the scale set.
+	HealthState string
+}
+
+// GetVirtualMachineScaleSetsClient is a helper function that will setup an Azure Virtual Machine Scale Set client on your behalf
+func GetVirtualMachineScaleSetsClient(subscriptionID string) (*compute.VirtualMachineScaleSetsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VMSS client
+	vmssClient := compute.NewVirtualMachineScaleSetsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	vmssClient.Authorizer = *authorizer
+
+	return &vmssClient, nil
+}
+
+// GetVirtualMachineScaleSetVMsClient is a helper function that will setup an Azure Virtual Machine Scale Set VM client on your behalf
+func GetVirtualMachineScaleSetVMsClient(subscriptionID string) (*compute.VirtualMachineScaleSetVMsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VMSS VM client
+	vmssVMClient := compute.NewVirtualMachineScaleSetVMsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	vmssVMClient.Authorizer = *authorizer
+
+	return &vmssVMClient, nil
+}
+
+// GetVirtualMachineScaleSet gets the properties of a Virtual Machine Scale Set in Azure by Name
+func GetVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) compute.VirtualMachineScaleSet {
+	vmss, err := GetVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return vmss
+}
+
+// GetVirtualMachineScaleSetE gets the properties of a Virtual Machine Scale Set in Azure by Name
+func GetVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) (compute.VirtualMachineScaleSet, error) {
+	vmss := compute.VirtualMachineScaleSet{}
+
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return vmss, err
+	}
+
+	// Create a VMSS client
+	vmssClient, err := GetVirtualMachineScaleSetsClient(subscriptionID)
+	if err != nil {
+		return vmss, err
+	}
+
+	// Get the details of the target scale set
+	vmss, err = vmssClient.Get(context.Background(), resGroupName, vmssName, "")
+	if err != nil {
+		return vmss, err
+	}
+
+	return vmss, nil
+}
+
+// GetSkuOfVirtualMachineScaleSet gets the SKU name of the given Virtual Machine Scale Set, e.g. Standard_D2s_v3
+func GetSkuOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) string {
+	sku, err := GetSkuOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return sku
+}
+
+// GetSkuOfVirtualMachineScaleSetE gets the SKU name of the given Virtual Machine Scale Set, e.g. Standard_D2s_v3
+func GetSkuOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) (string, error) {
+	vmss, err := GetVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	if vmss.Sku == nil || vmss.Sku.Name == nil {
+		return "", nil
+	}
+
+	return *vmss.Sku.Name, nil
+}
+
+// GetCapacityOfVirtualMachineScaleSet gets the number of instances configured on the given Virtual Machine Scale Set
+func GetCapacityOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) int64 {
+	capacity, err := GetCapacityOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return capacity
+}
+
+// GetCapacityOfVirtualMachineScaleSetE gets the number of instances configured on the given Virtual Machine Scale Set
+func GetCapacityOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) (int64, error) {
+	vmss, err := GetVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	if vmss.Sku == nil || vmss.Sku.Capacity == nil {
+		return 0, nil
+	}
+
+	return *vmss.Sku.Capacity, nil
+}
+
+// GetUpgradePolicyOfVirtualMachineScaleSet gets the upgrade mode of the given Virtual Machine Scale Set
+func GetUpgradePolicyOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) compute.UpgradeMode {
+	mode, err := GetUpgradePolicyOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return mode
+}
+
+// GetUpgradePolicyOfVirtualMachineScaleSetE gets the upgrade mode of the given Virtual Machine Scale Set
+func GetUpgradePolicyOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) (compute.UpgradeMode, error) {
+	vmss, err := GetVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	if vmss.VirtualMachineScaleSetProperties == nil || vmss.UpgradePolicy == nil {
+		return "", nil
+	}
+
+	return vmss.UpgradePolicy.Mode, nil
+}
+
+// GetZonesOfVirtualMachineScaleSet gets the availability zones the given Virtual Machine Scale Set is spread across
+func GetZonesOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) []string {
+	zones, err := GetZonesOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return zones
+}
+
+// GetZonesOfVirtualMachineScaleSetE gets the availability zones the given Virtual Machine Scale Set is spread across
+func GetZonesOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) ([]string, error) {
+	vmss, err := GetVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := []string{}
+	if vmss.Zones != nil {
+		zones = append(zones, *vmss.Zones...)
+	}
+
+	return zones, nil
+}
+
+// GetImageReferenceOfVirtualMachineScaleSet gets the image reference the instances of the given Virtual Machine Scale Set are built from
+func GetImageReferenceOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) compute.ImageReference {
+	image, err := GetImageReferenceOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return image
+}
+
+// GetImageReferenceOfVirtualMachineScaleSetE gets the image reference the instances of the given Virtual Machine Scale Set are built from
+func GetImageReferenceOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) (compute.ImageReference, error) {
+	image := compute.ImageReference{}
+
+	vmss, err := GetVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return image, err
+	}
+
+	if vmss.VirtualMachineScaleSetProperties == nil || vmss.VirtualMachineProfile == nil ||
+		vmss.VirtualMachineProfile.StorageProfile == nil || vmss.VirtualMachineProfile.StorageProfile.ImageReference == nil {
+		return image, nil
+	}
+
+	return *vmss.VirtualMachineProfile.StorageProfile.ImageReference, nil
+}
+
+// GetInstancesOfVirtualMachineScaleSet gets the provisioning, power and health state of every instance in the given Virtual Machine Scale Set
+func GetInstancesOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) []VMSSInstance {
+	instances, err := GetInstancesOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return instances
+}
+
+// GetInstancesOfVirtualMachineScaleSetE gets the provisioning, power and health state of every instance in the given Virtual Machine Scale Set
+func GetInstancesOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) ([]VMSSInstance, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VMSS VM client
+	vmssVMClient, err := GetVirtualMachineScaleSetVMsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// List the instances of the scale set, expanding their instance views
+	iterator, err := vmssVMClient.ListComplete(context.Background(), resGroupName, vmssName, "", "", "instanceView")
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []VMSSInstance{}
+	for iterator.NotDone() {
+		instances = append(instances, newVMSSInstance(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return instances, nil
+}
+
+// GetHealthOfVirtualMachineScaleSetInstances gets the Application Health extension state of each instance in the given
+// Virtual Machine Scale Set, keyed by instance ID
+func GetHealthOfVirtualMachineScaleSetInstances(t *testing.T, resGroupName string, vmssName string, subscriptionID string) map[string]string {
+	health, err := GetHealthOfVirtualMachineScaleSetInstancesE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return health
+}
+
+// GetHealthOfVirtualMachineScaleSetInstancesE gets the Application Health extension state of each instance in the given
+// Virtual Machine Scale Set, keyed by instance ID
+func GetHealthOfVirtualMachineScaleSetInstancesE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) (map[string]string, error) {
+	instances, err := GetInstancesOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	health := make(map[string]string)
+	for _, instance := range instances {
+		health[instance.InstanceID] = instance.HealthState
+	}
+
+	return health, nil
+}
+
+// GetNetworkInterfacesOfVirtualMachineScaleSet gets the IDs of the NICs attached to the instances of the given Virtual Machine Scale Set
+func GetNetworkInterfacesOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) []string {
+	nics, err := GetNetworkInterfacesOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return nics
+}
+
+// GetNetworkInterfacesOfVirtualMachineScaleSetE gets the IDs of the NICs attached to the instances of the given Virtual Machine Scale Set
+func GetNetworkInterfacesOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) ([]string, error) {
+	nics, err := listVirtualMachineScaleSetNetworkInterfaces(resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	nicIDs := []string{}
+	for _, nic := range nics {
+		if nic.ID == nil {
+			continue
+		}
+		nicIDs = append(nicIDs, *nic.ID)
+	}
+
+	return nicIDs, nil
+}
+
+// GetPrivateIPsOfVirtualMachineScaleSet gets the private IP addresses of every instance in the given Virtual Machine Scale Set
+func GetPrivateIPsOfVirtualMachineScaleSet(t *testing.T, resGroupName string, vmssName string, subscriptionID string) []string {
+	ips, err := GetPrivateIPsOfVirtualMachineScaleSetE(t, resGroupName, vmssName, subscriptionID)
+	require.NoError(t, err)
+
+	return ips
+}
+
+// GetPrivateIPsOfVirtualMachineScaleSetE gets the private IP addresses of every instance in the given Virtual Machine Scale Set
+func GetPrivateIPsOfVirtualMachineScaleSetE(t *testing.T, resGroupName string, vmssName string, subscriptionID string) ([]string, error) {
+	nics, err := listVirtualMachineScaleSetNetworkInterfaces(resGroupName, vmssName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	for _, nic := range nics {
+		ips = append(ips, newNetworkInterface(nic).PrivateIPAddresses()...)
+	}
+
+	return ips, nil
+}
+
+// listVirtualMachineScaleSetNetworkInterfaces lists every NIC attached to the instances of a Virtual Machine Scale Set
+func listVirtualMachineScaleSetNetworkInterfaces(resGroupName string, vmssName string, subscriptionID string) ([]network.Interface, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a NIC client
+	nicClient, err := GetNetworkInterfacesClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := nicClient.ListVirtualMachineScaleSetNetworkInterfacesComplete(context.Background(), resGroupName, vmssName)
+	if err != nil {
+		return nil, err
+	}
+
+	nics := []network.Interface{}
+	for iterator.NotDone() {
+		nics = append(nics, iterator.Value())
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return nics, nil
+}
+
+// newVMSSInstance converts a scale set VM returned by the API into a VMSSInstance
+func newVMSSInstance(vm compute.VirtualMachineScaleSetVM) VMSSInstance {
+	instance := VMSSInstance{Zones: []string{}}
+
+	if vm.InstanceID != nil {
+		instance.InstanceID = *vm.InstanceID
+	}
+	if vm.Name != nil {
+		instance.Name = *vm.Name
+	}
+	if vm.Zones != nil {
+		instance.Zones = append(instance.Zones, *vm.Zones...)
+	}
+
+	props := vm.VirtualMachineScaleSetVMProperties
+	if props == nil {
+		return instance
+	}
+
+	if props.ProvisioningState != nil {
+		instance.ProvisioningState = *props.ProvisioningState
+	}
+	if props.LatestModelApplied != nil {
+		instance.LatestModelApplied = *props.LatestModelApplied
+	}
+
+	if props.InstanceView != nil {
+		instance.PowerState = getPowerStateFromStatuses(props.InstanceView.Statuses)
+
+		health := props.InstanceView.VMHealth
+		if health != nil && health.Status != nil && health.Status.Code != nil {
+			instance.HealthState = statusCodeValue(*health.Status.Code)
+		}
+	}
+
+	return instance
+}
@@ -0,0 +1,62 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-03-01/compute"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVMSSInstance(t *testing.T) {
+	t.Parallel()
+
+	instanceID := "3"
+	name := "vmss_3"
+	provisioningState := "Succeeded"
+	latest := true
+	powerCode := "PowerState/running"
+	healthCode := "HealthState/healthy"
+
+	vm := compute.VirtualMachineScaleSetVM{
+		InstanceID: &instanceID,
+		Name:       &name,
+		Zones:      &[]string{"2"},
+		VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+			ProvisioningState:  &provisioningState,
+			LatestModelApplied: &latest,
+			InstanceView: &compute.VirtualMachineScaleSetVMInstanceView{
+				Statuses: &[]compute.InstanceViewStatus{{Code: &powerCode}},
+				VMHealth: &compute.VirtualMachineHealthStatus{Status: &compute.InstanceViewStatus{Code: &healthCode}},
+			},
+		},
+	}
+
+	want := VMSSInstance{
+		InstanceID:         "3",
+		Name:               "vmss_3",
+		Zones:              []string{"2"},
+		ProvisioningState:  "Succeeded",
+		LatestModelApplied: true,
+		PowerState:         "running",
+		HealthState:        "healthy",
+	}
+
+	require.Equal(t, want, newVMSSInstance(vm))
+}
+
+func TestGetInstancesOfVirtualMachineScaleSetE(t *testing.T) {
+	t.Parallel()
+
+	rgName := ""
+	vmssName := ""
+	subID := ""
+
+	_, err := GetInstancesOfVirtualMachineScaleSetE(t, rgName, vmssName, subID)
+
+	require.Error(t, err)
+}
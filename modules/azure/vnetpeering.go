@@ -0,0 +1,210 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// VnetPeering describes one side of an Azure Virtual Network peering. A peering only carries traffic once both sides
+// exist and report a PeeringState of Connected.
+type VnetPeering struct {
+	ID                    string
+	Name                  string
+	VnetID                string
+	RemoteVnetID          string
+	RemoteAddressPrefixes []string
+
+	// PeeringState is Initiated, Connected or Disconnected, while PeeringSyncLevel tells whether the address space of
+	// the remote VNet changed since the peering was last synced
+	PeeringState      string
+	PeeringSyncLevel  string
+	ProvisioningState string
+
+	AllowVirtualNetworkAccess bool
+	AllowForwardedTraffic     bool
+	AllowGatewayTransit       bool
+	UseRemoteGateways         bool
+}
+
+// GetVirtualNetworkPeeringsClient is a helper function that will setup an Azure VNet Peering client on your behalf
+func GetVirtualNetworkPeeringsClient(subscriptionID string) (*network.VirtualNetworkPeeringsClient, error) {
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VNet Peering client
+	peeringClient := network.NewVirtualNetworkPeeringsClient(subscriptionID)
+
+	// Create an authorizer
+	authorizer, err := NewAuthorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach authorizer to the client
+	peeringClient.Authorizer = *authorizer
+
+	return &peeringClient, nil
+}
+
+// GetVnetPeerings gets the peerings of the given Azure Virtual Network
+func GetVnetPeerings(t *testing.T, resGroupName string, vNetName string, subscriptionID string) []VnetPeering {
+	peerings, err := GetVnetPeeringsE(t, resGroupName, vNetName, subscriptionID)
+	require.NoError(t, err)
+
+	return peerings
+}
+
+// GetVnetPeeringsE gets the peerings of the given Azure Virtual Network
+func GetVnetPeeringsE(t *testing.T, resGroupName string, vNetName string, subscriptionID string) ([]VnetPeering, error) {
+	// Validate resource group name and subscription ID
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a VNet Peering client
+	peeringClient, err := GetVirtualNetworkPeeringsClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := peeringClient.ListComplete(context.Background(), resGroupName, vNetName)
+	if err != nil {
+		return nil, err
+	}
+
+	peerings := []VnetPeering{}
+	for iterator.NotDone() {
+		peerings = append(peerings, newVnetPeering(iterator.Value()))
+
+		if err := iterator.NextWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return peerings, nil
+}
+
+// GetVnetPeeringToRemoteVnet gets the peering of a VNet, given by its resource ID, that points at a remote VNet. The
+// VNets may be in different resource groups or subscriptions.
+func GetVnetPeeringToRemoteVnet(t *testing.T, vnetID string, remoteVnetID string) VnetPeering {
+	peering, err := GetVnetPeeringToRemoteVnetE(t, vnetID, remoteVnetID)
+	require.NoError(t, err)
+
+	return peering
+}
+
+// GetVnetPeeringToRemoteVnetE gets the peering of a VNet, given by its resource ID, that points at a remote VNet. The
+// VNets may be in different resource groups or subscriptions.
+func GetVnetPeeringToRemoteVnetE(t *testing.T, vnetID string, remoteVnetID string) (VnetPeering, error) {
+	id, err := parseAzureResourceID(vnetID)
+	if err != nil {
+		return VnetPeering{}, err
+	}
+
+	peerings, err := GetVnetPeeringsE(t, id.ResourceGroup, id.Name, id.SubscriptionID)
+	if err != nil {
+		return VnetPeering{}, err
+	}
+
+	for _, peering := range peerings {
+		if strings.EqualFold(peering.RemoteVnetID, remoteVnetID) {
+			return peering, nil
+		}
+	}
+
+	return VnetPeering{}, VnetPeeringNotFound{VnetID: vnetID, RemoteVnetID: remoteVnetID}
+}
+
+// AssertVnetsPeered checks that two VNets, given by their resource IDs, are peered in both directions. Both sides must
+// be Connected and allow VNet access, and gateway transit must be set up consistently: a side may only use remote
+// gateways if the other side allows gateway transit, and not both sides at once. The VNets may be in different
+// subscriptions, as long as the test identity can read both.
+func AssertVnetsPeered(t *testing.T, vnetAID string, vnetBID string) {
+	peeringA, errA := GetVnetPeeringToRemoteVnetE(t, vnetAID, vnetBID)
+	peeringB, errB := GetVnetPeeringToRemoteVnetE(t, vnetBID, vnetAID)
+	require.NoError(t, errA)
+	require.NoError(t, errB)
+
+	for _, problem := range checkVnetPeeringPair(peeringA, peeringB) {
+		assert.Fail(t, "VNet peering is not healthy", problem)
+	}
+}
+
+// checkVnetPeeringPair returns the reasons the two sides of a peering cannot carry traffic as configured
+func checkVnetPeeringPair(peeringA VnetPeering, peeringB VnetPeering) []string {
+	problems := []string{}
+
+	for _, peering := range []VnetPeering{peeringA, peeringB} {
+		if peering.PeeringState != string(network.VirtualNetworkPeeringStateConnected) {
+			problems = append(problems, fmt.Sprintf("Peering %s of %s is %s, not Connected", peering.Name, peering.VnetID, peering.PeeringState))
+		}
+		if !peering.AllowVirtualNetworkAccess {
+			problems = append(problems, fmt.Sprintf("Peering %s of %s does not allow virtual network access", peering.Name, peering.VnetID))
+		}
+	}
+
+	if peeringA.UseRemoteGateways && peeringB.UseRemoteGateways {
+		problems = append(problems, fmt.Sprintf("Peerings %s and %s both use remote gateways", peeringA.Name, peeringB.Name))
+	}
+	if peeringA.UseRemoteGateways && !peeringB.AllowGatewayTransit {
+		problems = append(problems, fmt.Sprintf("Peering %s uses remote gateways but %s does not allow gateway transit", peeringA.Name, peeringB.Name))
+	}
+	if peeringB.UseRemoteGateways && !peeringA.AllowGatewayTransit {
+		problems = append(problems, fmt.Sprintf("Peering %s uses remote gateways but %s does not allow gateway transit", peeringB.Name, peeringA.Name))
+	}
+
+	return problems
+}
+
+// newVnetPeering converts a VNet peering returned by the API into a VnetPeering
+func newVnetPeering(peering network.VirtualNetworkPeering) VnetPeering {
+	result := VnetPeering{RemoteAddressPrefixes: []string{}}
+
+	if peering.ID != nil {
+		result.ID = *peering.ID
+		if id, err := parseAzureResourceID(*peering.ID); err == nil {
+			result.VnetID = id.ParentID
+		}
+	}
+	if peering.Name != nil {
+		result.Name = *peering.Name
+	}
+
+	props := peering.VirtualNetworkPeeringPropertiesFormat
+	if props == nil {
+		return result
+	}
+
+	result.RemoteVnetID = getSubResourceID(props.RemoteVirtualNetwork)
+	result.PeeringState = string(props.PeeringState)
+	result.PeeringSyncLevel = string(props.PeeringSyncLevel)
+	result.ProvisioningState = string(props.ProvisioningState)
+
+	if props.RemoteAddressSpace != nil && props.RemoteAddressSpace.AddressPrefixes != nil {
+		result.RemoteAddressPrefixes = *props.RemoteAddressSpace.AddressPrefixes
+	}
+	if props.AllowVirtualNetworkAccess != nil {
+		result.AllowVirtualNetworkAccess = *props.AllowVirtualNetworkAccess
+	}
+	if props.AllowForwardedTraffic != nil {
+		result.AllowForwardedTraffic = *props.AllowForwardedTraffic
+	}
+	if props.AllowGatewayTransit != nil {
+		result.AllowGatewayTransit = *props.AllowGatewayTransit
+	}
+	if props.UseRemoteGateways != nil {
+		result.UseRemoteGateways = *props.UseRemoteGateways
+	}
+
+	return result
+}
@@ -0,0 +1,76 @@
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2022-07-01/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVnetPeering(t *testing.T) {
+	t.Parallel()
+
+	peeringID := "/subscriptions/sub-a/resourceGroups/rg-a/providers/Microsoft.Network/virtualNetworks/hub/virtualNetworkPeerings/hub-to-spoke"
+	remoteVnetID := "/subscriptions/sub-b/resourceGroups/rg-b/providers/Microsoft.Network/virtualNetworks/spoke"
+	allow := true
+
+	peering := newVnetPeering(network.VirtualNetworkPeering{
+		ID: &peeringID,
+		VirtualNetworkPeeringPropertiesFormat: &network.VirtualNetworkPeeringPropertiesFormat{
+			RemoteVirtualNetwork:      &network.SubResource{ID: &remoteVnetID},
+			PeeringState:              network.VirtualNetworkPeeringStateConnected,
+			AllowVirtualNetworkAccess: &allow,
+			AllowGatewayTransit:       &allow,
+		},
+	})
+
+	require.Equal(t, "/subscriptions/sub-a/resourceGroups/rg-a/providers/Microsoft.Network/virtualNetworks/hub", peering.VnetID)
+	require.Equal(t, remoteVnetID, peering.RemoteVnetID)
+	require.Equal(t, "Connected", peering.PeeringState)
+	require.True(t, peering.AllowGatewayTransit)
+	require.False(t, peering.UseRemoteGateways)
+}
+
+func TestCheckVnetPeeringPair(t *testing.T) {
+	t.Parallel()
+
+	hub := VnetPeering{Name: "hub-to-spoke", PeeringState: "Connected", AllowVirtualNetworkAccess: true, AllowGatewayTransit: true}
+	spoke := VnetPeering{Name: "spoke-to-hub", PeeringState: "Connected", AllowVirtualNetworkAccess: true, UseRemoteGateways: true}
+	disconnected := VnetPeering{Name: "spoke-to-hub", PeeringState: "Disconnected", AllowVirtualNetworkAccess: true}
+	noTransit := VnetPeering{Name: "hub-to-spoke", PeeringState: "Connected", AllowVirtualNetworkAccess: true}
+
+	tests := []struct {
+		name         string
+		peeringA     VnetPeering
+		peeringB     VnetPeering
+		wantProblems int
+	}{
+		{name: "HubAndSpoke", peeringA: hub, peeringB: spoke, wantProblems: 0},
+		{name: "Disconnected", peeringA: hub, peeringB: disconnected, wantProblems: 1},
+		{name: "GatewayTransitNotAllowed", peeringA: noTransit, peeringB: spoke, wantProblems: 1},
+		{name: "BothUseRemoteGateways", peeringA: spoke, peeringB: spoke, wantProblems: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkVnetPeeringPair(tt.peeringA, tt.peeringB)
+
+			require.Len(t, got, tt.wantProblems)
+		})
+	}
+}
+
+func TestGetVnetPeeringToRemoteVnetE(t *testing.T) {
+	t.Parallel()
+
+	vnetID := ""
+	remoteVnetID := ""
+
+	_, err := GetVnetPeeringToRemoteVnetE(t, vnetID, remoteVnetID)
+	require.Error(t, err)
+}